@@ -0,0 +1,116 @@
+// bumpversion - bump the version in the VERSION file, regenerate version.go from a template, and tag it in git.
+//
+//	bumpversion [flags] [major|minor|patch]
+//
+// Without a part the version is not changed, so it can regenerate version.go with go:generate:
+//
+//	//go:generate go run github.com/zhs007/goutils/cmd/bumpversion -template version.temp
+//
+// The template is executed with the goutils.VersionObj, like
+//
+//	const Version = "{{.String}}"
+//
+// The VERSION file is parsed with goutils.ParseVersionLenient, so "1.2" and "v1.20.03" are accepted.
+//
+// -tag creates an annotated tag of the version on HEAD, so bump and commit first, then run it again with -tag only,
+// -tag with a part or -pre is rejected, because HEAD does not have the new version yet.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+
+	"github.com/zhs007/goutils"
+)
+
+func main() {
+	fnVersion := flag.String("version", "VERSION", "the VERSION file")
+	fnTemplate := flag.String("template", "", "the template of the go file, the go file is not generated if it is empty")
+	fnOutput := flag.String("output", "version.go", "the go file")
+	pre := flag.String("pre", "", "set the pre-release like rc.1, -pre= removes it")
+	isTag := flag.Bool("tag", false, "create a git tag of the version")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: bumpversion [flags] [major|minor|patch]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() > 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	isPre := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "pre" {
+			isPre = true
+		}
+	})
+
+	if *isTag && (flag.NArg() == 1 || isPre) {
+		fmt.Fprintf(flag.CommandLine.Output(), "bumpversion: -tag cannot be used with a part or -pre, commit the new version first\n")
+		os.Exit(2)
+	}
+
+	str, err := goutils.LoadVersion(*fnVersion)
+	if err != nil {
+		goutils.Error("bumpversion:LoadVersion",
+			slog.String("fn", *fnVersion),
+			goutils.Err(err))
+
+		os.Exit(1)
+	}
+
+	vobj, err := goutils.ParseVersionLenient(str)
+	if err != nil {
+		os.Exit(1)
+	}
+
+	if flag.NArg() == 1 {
+		err = vobj.Bump(flag.Arg(0))
+		if err != nil {
+			os.Exit(1)
+		}
+	}
+
+	if isPre {
+		err = vobj.SetPreRelease(*pre)
+		if err != nil {
+			os.Exit(1)
+		}
+	}
+
+	if flag.NArg() == 1 || isPre {
+		err = goutils.SaveVersion(*fnVersion, vobj)
+		if err != nil {
+			os.Exit(1)
+		}
+	}
+
+	if *fnTemplate != "" {
+		err = goutils.BuildVersionFile(*fnOutput, *fnTemplate, vobj)
+		if err != nil {
+			os.Exit(1)
+		}
+	}
+
+	if *isTag {
+		cmd := exec.Command("git", "tag", "-a", vobj.String(), "-m", vobj.String())
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+
+		err = cmd.Run()
+		if err != nil {
+			goutils.Error("bumpversion:git tag",
+				slog.String("version", vobj.String()),
+				goutils.Err(err))
+
+			os.Exit(1)
+		}
+	}
+
+	fmt.Println(vobj.String())
+}
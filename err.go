@@ -24,4 +24,38 @@ var (
 
 	// ErrInvalidNameInMapWeights - invalid Name in MapWeights
 	ErrInvalidNameInMapWeights = errors.New("invalid Name in MapWeights")
+	// ErrEmptyMapWeights - empty MapWeights
+	ErrEmptyMapWeights = errors.New("empty MapWeights")
+	// ErrInvalidSamples - invalid samples
+	ErrInvalidSamples = errors.New("invalid samples")
+
+	// ErrInvalidAlphabet - invalid alphabet
+	ErrInvalidAlphabet = errors.New("invalid alphabet")
+	// ErrIDOverflow - id overflow
+	ErrIDOverflow = errors.New("id overflow")
+	// ErrInvalidNodeID - invalid node id
+	ErrInvalidNodeID = errors.New("invalid node id")
+
+	// ErrHTTPServerError - http server error
+	ErrHTTPServerError = errors.New("http server error")
+	// ErrTrackPanic - the tracked handler panicked
+	ErrTrackPanic = errors.New("tracked handler panicked")
+	// ErrInvalidStatsSnapshotType - invalid StatsSnapshot type
+	ErrInvalidStatsSnapshotType = errors.New("invalid StatsSnapshot type")
+	// ErrAlreadyStarted - already started
+	ErrAlreadyStarted = errors.New("already started")
+
+	// ErrInvalidLoggerLevel - invalid logger level
+	ErrInvalidLoggerLevel = errors.New("invalid logger level")
+	// ErrInvalidLoggerFormat - invalid logger format
+	ErrInvalidLoggerFormat = errors.New("invalid logger format")
+	// ErrInvalidLoggerOutput - invalid logger output
+	ErrInvalidLoggerOutput = errors.New("invalid logger output")
+	// ErrInvalidRedactMode - invalid redact mode
+	ErrInvalidRedactMode = errors.New("invalid redact mode")
+
+	// ErrInvalidVersionConstraint - invalid version constraint
+	ErrInvalidVersionConstraint = errors.New("invalid version constraint")
+	// ErrInvalidVersionPart - invalid version part
+	ErrInvalidVersionPart = errors.New("invalid version part")
 )
@@ -0,0 +1,310 @@
+package goutils
+
+import (
+	"math"
+	"sort"
+)
+
+// DefaultHistogramAccuracy - default relative accuracy of Histogram, 1%
+const DefaultHistogramAccuracy = 0.01
+
+// histogramMinValue - values <= histogramMinValue are counted in ZeroCount
+const histogramMinValue = 1e-9
+
+// HistogramBucket - a bucket of Histogram, (Lower, Upper]
+type HistogramBucket struct {
+	Lower float64 `json:"lower"`
+	Upper float64 `json:"upper"`
+	Count int64   `json:"count"`
+}
+
+// Histogram - log-bucketed histogram, every recorded value is kept with a relative error <= RelativeAccuracy.
+//
+//	bucket i is (gamma^(i-1), gamma^i], gamma = (1 + RelativeAccuracy) / (1 - RelativeAccuracy)
+//
+// It is not thread-safe.
+type Histogram struct {
+	RelativeAccuracy float64       `json:"relativeAccuracy"`
+	Buckets          map[int]int64 `json:"buckets,omitempty"`
+	ZeroCount        int64         `json:"zeroCount,omitempty"`
+	Count            int64         `json:"count"`
+	Sum              float64       `json:"sum"`
+	Min              float64       `json:"min"`
+	Max              float64       `json:"max"`
+	gamma            float64       `json:"-"`
+	lnGamma          float64       `json:"-"`
+}
+
+// NewHistogram - new Histogram, relativeAccuracy should be in (0, 1), like 0.01
+func NewHistogram(relativeAccuracy float64) *Histogram {
+	if relativeAccuracy <= 0 || relativeAccuracy >= 1 {
+		relativeAccuracy = DefaultHistogramAccuracy
+	}
+
+	h := &Histogram{
+		RelativeAccuracy: relativeAccuracy,
+		Buckets:          make(map[int]int64),
+	}
+
+	h.init()
+
+	return h
+}
+
+// init - gamma is not serialized, so it is rebuilt after unmarshal
+func (h *Histogram) init() {
+	if h.gamma > 0 {
+		return
+	}
+
+	if h.RelativeAccuracy <= 0 || h.RelativeAccuracy >= 1 {
+		h.RelativeAccuracy = DefaultHistogramAccuracy
+	}
+
+	h.gamma = (1 + h.RelativeAccuracy) / (1 - h.RelativeAccuracy)
+	h.lnGamma = math.Log(h.gamma)
+
+	if h.Buckets == nil {
+		h.Buckets = make(map[int]int64)
+	}
+}
+
+func (h *Histogram) index(v float64) int {
+	return int(math.Ceil(math.Log(v) / h.lnGamma))
+}
+
+func (h *Histogram) lowerBound(i int) float64 {
+	return math.Exp(float64(i-1) * h.lnGamma)
+}
+
+func (h *Histogram) upperBound(i int) float64 {
+	return math.Exp(float64(i) * h.lnGamma)
+}
+
+// value - the value in bucket i with the smallest relative error
+func (h *Histogram) value(i int) float64 {
+	return 2 * h.upperBound(i) / (h.gamma + 1)
+}
+
+// Record - record a value
+func (h *Histogram) Record(v float64) {
+	h.RecordN(v, 1)
+}
+
+// RecordN - record a value n times
+func (h *Histogram) RecordN(v float64, n int64) {
+	if n <= 0 || math.IsNaN(v) {
+		return
+	}
+
+	h.init()
+
+	if h.Count == 0 || v < h.Min {
+		h.Min = v
+	}
+
+	if h.Count == 0 || v > h.Max {
+		h.Max = v
+	}
+
+	h.Count += n
+	h.Sum += v * float64(n)
+
+	if v <= histogramMinValue {
+		h.ZeroCount += n
+
+		return
+	}
+
+	h.Buckets[h.index(v)] += n
+}
+
+// Reset - clear all values
+func (h *Histogram) Reset() {
+	h.init()
+
+	h.Buckets = make(map[int]int64)
+	h.ZeroCount = 0
+	h.Count = 0
+	h.Sum = 0
+	h.Min = 0
+	h.Max = 0
+}
+
+// Clone - deep copy
+func (h *Histogram) Clone() *Histogram {
+	h.init()
+
+	nh := &Histogram{
+		RelativeAccuracy: h.RelativeAccuracy,
+		Buckets:          make(map[int]int64, len(h.Buckets)),
+		ZeroCount:        h.ZeroCount,
+		Count:            h.Count,
+		Sum:              h.Sum,
+		Min:              h.Min,
+		Max:              h.Max,
+		gamma:            h.gamma,
+		lnGamma:          h.lnGamma,
+	}
+
+	for k, v := range h.Buckets {
+		nh.Buckets[k] = v
+	}
+
+	return nh
+}
+
+// Merge - add all values of o, buckets are added directly if the accuracies are the same
+func (h *Histogram) Merge(o *Histogram) {
+	if o == nil || o.Count == 0 {
+		return
+	}
+
+	h.init()
+	o.init()
+
+	if h.Count == 0 || o.Min < h.Min {
+		h.Min = o.Min
+	}
+
+	if h.Count == 0 || o.Max > h.Max {
+		h.Max = o.Max
+	}
+
+	h.Count += o.Count
+	h.Sum += o.Sum
+	h.ZeroCount += o.ZeroCount
+
+	if h.RelativeAccuracy == o.RelativeAccuracy {
+		for k, v := range o.Buckets {
+			h.Buckets[k] += v
+		}
+
+		return
+	}
+
+	// different accuracies, every bucket of o is added with its representative value
+	for k, v := range o.Buckets {
+		h.Buckets[h.index(o.value(k))] += v
+	}
+}
+
+// Avg - average of all values
+func (h *Histogram) Avg() float64 {
+	if h.Count == 0 {
+		return 0
+	}
+
+	return h.Sum / float64(h.Count)
+}
+
+func (h *Histogram) sortedIndexes() []int {
+	indexes := make([]int, 0, len(h.Buckets))
+	for k, v := range h.Buckets {
+		if v > 0 {
+			indexes = append(indexes, k)
+		}
+	}
+
+	sort.Ints(indexes)
+
+	return indexes
+}
+
+// Percentile - q is in [0, 100], like 99.9
+func (h *Histogram) Percentile(q float64) float64 {
+	if h.Count == 0 {
+		return 0
+	}
+
+	h.init()
+
+	if q <= 0 {
+		return h.Min
+	}
+
+	if q >= 100 {
+		return h.Max
+	}
+
+	rank := int64(math.Ceil(q / 100 * float64(h.Count)))
+	if rank < 1 {
+		rank = 1
+	}
+
+	cur := h.ZeroCount
+	if cur >= rank {
+		return h.Min
+	}
+
+	for _, i := range h.sortedIndexes() {
+		cur += h.Buckets[i]
+		if cur >= rank {
+			v := h.value(i)
+			if v < h.Min {
+				return h.Min
+			}
+
+			if v > h.Max {
+				return h.Max
+			}
+
+			return v
+		}
+	}
+
+	return h.Max
+}
+
+// CountLessOrEqual - number of values <= v, a bucket is counted by its representative value
+func (h *Histogram) CountLessOrEqual(v float64) int64 {
+	if h.Count == 0 {
+		return 0
+	}
+
+	if v >= h.Max {
+		return h.Count
+	}
+
+	if v < h.Min {
+		return 0
+	}
+
+	h.init()
+
+	cur := h.ZeroCount
+	for _, i := range h.sortedIndexes() {
+		if h.value(i) > v {
+			break
+		}
+
+		cur += h.Buckets[i]
+	}
+
+	return cur
+}
+
+// BucketCounts - non-empty buckets sorted by bounds, values <= 1e-9 are in the first bucket (0, 1e-9]
+func (h *Histogram) BucketCounts() []*HistogramBucket {
+	h.init()
+
+	lst := []*HistogramBucket{}
+
+	if h.ZeroCount > 0 {
+		lst = append(lst, &HistogramBucket{
+			Lower: 0,
+			Upper: histogramMinValue,
+			Count: h.ZeroCount,
+		})
+	}
+
+	for _, i := range h.sortedIndexes() {
+		lst = append(lst, &HistogramBucket{
+			Lower: h.lowerBound(i),
+			Upper: h.upperBound(i),
+			Count: h.Buckets[i],
+		})
+	}
+
+	return lst
+}
@@ -0,0 +1,94 @@
+package goutils
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Histogram(t *testing.T) {
+	h := NewHistogram(0.01)
+	assert.Equal(t, h.Percentile(50), 0.0)
+
+	for i := 1; i <= 1000; i++ {
+		h.Record(float64(i))
+	}
+
+	assert.Equal(t, h.Count, int64(1000))
+	assert.Equal(t, h.Min, 1.0)
+	assert.Equal(t, h.Max, 1000.0)
+	assert.InDelta(t, h.Avg(), 500.5, 1e-9)
+
+	for _, q := range []float64{1, 10, 50, 90, 99, 99.9} {
+		v := h.Percentile(q)
+		assert.InDelta(t, v, q*10, q*10*0.01+1e-9, q)
+	}
+
+	assert.Equal(t, h.Percentile(0), 1.0)
+	assert.Equal(t, h.Percentile(100), 1000.0)
+
+	total := int64(0)
+	last := 0.0
+	for _, b := range h.BucketCounts() {
+		assert.True(t, b.Lower >= last)
+		assert.True(t, b.Upper > b.Lower)
+		assert.True(t, (b.Upper-b.Lower)/b.Upper <= 0.02+1e-9)
+
+		total += b.Count
+		last = b.Upper
+	}
+	assert.Equal(t, total, int64(1000))
+
+	h.RecordN(0, 10)
+	assert.Equal(t, h.ZeroCount, int64(10))
+	assert.Equal(t, h.Min, 0.0)
+	assert.Equal(t, h.Percentile(0.5), 0.0)
+	assert.Equal(t, h.BucketCounts()[0].Count, int64(10))
+
+	h.Record(math.NaN())
+	assert.Equal(t, h.Count, int64(1010))
+
+	h1 := h.Clone()
+	h.Reset()
+	assert.Equal(t, h.Count, int64(0))
+	assert.Equal(t, len(h.Buckets), 0)
+	assert.Equal(t, h1.Count, int64(1010))
+
+	// an unmarshaled Histogram has no gamma
+	h2 := &Histogram{RelativeAccuracy: 0.02}
+	h2.Record(0.5)
+	assert.InDelta(t, h2.Percentile(50), 0.5, 0.5*0.02)
+
+	t.Logf("Test_Histogram OK")
+}
+
+func Test_HistogramMerge(t *testing.T) {
+	h0 := NewHistogram(0.01)
+	h1 := NewHistogram(0.01)
+	h2 := NewHistogram(0.02)
+
+	for i := 1; i <= 500; i++ {
+		h0.Record(float64(i))
+		h1.Record(float64(i + 500))
+		h2.Record(float64(i + 1000))
+	}
+
+	h := NewHistogram(0.01)
+	h.Merge(h0)
+	h.Merge(h1)
+	h.Merge(nil)
+
+	assert.Equal(t, h.Count, int64(1000))
+	assert.Equal(t, h.Min, 1.0)
+	assert.Equal(t, h.Max, 1000.0)
+	assert.InDelta(t, h.Percentile(50), 500, 5)
+	assert.InDelta(t, h.Percentile(99), 990, 10)
+
+	h.Merge(h2)
+	assert.Equal(t, h.Count, int64(1500))
+	assert.Equal(t, h.Max, 1500.0)
+	assert.InDelta(t, h.Percentile(90), 1350, 1350*0.03)
+
+	t.Logf("Test_HistogramMerge OK")
+}
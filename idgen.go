@@ -0,0 +1,322 @@
+package goutils
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+const (
+	// AlphabetBase62 - A-Z, a-z, 0-9
+	AlphabetBase62 = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"
+	// AlphabetHex - lower case hex
+	AlphabetHex = "0123456789abcdef"
+	// AlphabetCrockford32 - Crockford's base32, used by ULID
+	AlphabetCrockford32 = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+)
+
+// GenSecureToken - generator a token with crypto/rand, every char is uniformly chosen from alphabet
+func GenSecureToken(length int, alphabet string) (string, error) {
+	if len(alphabet) < 2 || len(alphabet) > 256 {
+		Error("GenSecureToken",
+			slog.Int("alphabet", len(alphabet)),
+			Err(ErrInvalidAlphabet))
+
+		return "", ErrInvalidAlphabet
+	}
+
+	if length <= 0 {
+		return "", nil
+	}
+
+	// bytes >= limit are rejected, so every char has the same probability
+	limit := 256 - 256%len(alphabet)
+
+	token := make([]byte, 0, length)
+	buf := make([]byte, length+length/4+8)
+
+	for len(token) < length {
+		_, err := io.ReadFull(rand.Reader, buf)
+		if err != nil {
+			Error("GenSecureToken:ReadFull",
+				Err(err))
+
+			return "", err
+		}
+
+		for _, b := range buf {
+			if int(b) >= limit {
+				continue
+			}
+
+			token = append(token, alphabet[int(b)%len(alphabet)])
+			if len(token) >= length {
+				break
+			}
+		}
+	}
+
+	return string(token), nil
+}
+
+// ULIDGenerator - monotonic ULID generator
+type ULIDGenerator struct {
+	lock    sync.Mutex
+	timer   ITime
+	lastMs  uint64
+	lastRnd [10]byte
+}
+
+// NewULIDGenerator - new ULIDGenerator, timer is gTime if it is nil
+func NewULIDGenerator(timer ITime) *ULIDGenerator {
+	if timer == nil {
+		timer = gTime
+	}
+
+	return &ULIDGenerator{
+		timer: timer,
+	}
+}
+
+// New - new ULID, ULIDs from one generator are strictly increasing
+func (gen *ULIDGenerator) New() (string, error) {
+	gen.lock.Lock()
+	defer gen.lock.Unlock()
+
+	ms := uint64(gen.timer.Now().UnixMilli())
+
+	if ms <= gen.lastMs {
+		// same millisecond or clock moved backwards, increase the random part
+		isOverflow := true
+		for i := len(gen.lastRnd) - 1; i >= 0; i-- {
+			gen.lastRnd[i]++
+			if gen.lastRnd[i] != 0 {
+				isOverflow = false
+
+				break
+			}
+		}
+
+		if isOverflow {
+			Error("ULIDGenerator.New",
+				slog.Uint64("ms", gen.lastMs),
+				Err(ErrIDOverflow))
+
+			return "", ErrIDOverflow
+		}
+	} else {
+		_, err := io.ReadFull(rand.Reader, gen.lastRnd[:])
+		if err != nil {
+			Error("ULIDGenerator.New:ReadFull",
+				Err(err))
+
+			return "", err
+		}
+
+		gen.lastMs = ms
+	}
+
+	var id [16]byte
+	id[0] = byte(gen.lastMs >> 40)
+	id[1] = byte(gen.lastMs >> 32)
+	id[2] = byte(gen.lastMs >> 24)
+	id[3] = byte(gen.lastMs >> 16)
+	id[4] = byte(gen.lastMs >> 8)
+	id[5] = byte(gen.lastMs)
+	copy(id[6:], gen.lastRnd[:])
+
+	return encodeULID(id), nil
+}
+
+// encodeULID - 128 bits -> 26 chars of Crockford's base32
+func encodeULID(id [16]byte) string {
+	hi := binary.BigEndian.Uint64(id[:8])
+	lo := binary.BigEndian.Uint64(id[8:])
+
+	str := make([]byte, 26)
+	for i := 25; i >= 0; i-- {
+		str[i] = AlphabetCrockford32[lo&0x1f]
+
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+
+	return string(str)
+}
+
+// UUIDv7Generator - monotonic UUIDv7 generator, rand_a is used as a 12 bits counter (RFC 9562, method 1)
+type UUIDv7Generator struct {
+	lock    sync.Mutex
+	timer   ITime
+	lastMs  uint64
+	counter uint16
+}
+
+// NewUUIDv7Generator - new UUIDv7Generator, timer is gTime if it is nil
+func NewUUIDv7Generator(timer ITime) *UUIDv7Generator {
+	if timer == nil {
+		timer = gTime
+	}
+
+	return &UUIDv7Generator{
+		timer: timer,
+	}
+}
+
+// New - new UUIDv7, like 01890a5d-ac96-774b-bcce-b302099a8057
+func (gen *UUIDv7Generator) New() (string, error) {
+	var id [16]byte
+
+	_, err := io.ReadFull(rand.Reader, id[6:])
+	if err != nil {
+		Error("UUIDv7Generator.New:ReadFull",
+			Err(err))
+
+		return "", err
+	}
+
+	gen.lock.Lock()
+
+	ms := uint64(gen.timer.Now().UnixMilli())
+	if ms <= gen.lastMs {
+		gen.counter++
+		if gen.counter > 0xfff {
+			// counter overflow, borrow the next millisecond
+			gen.lastMs++
+			gen.counter = uint16(id[6]&0x07)<<8 | uint16(id[7])
+		}
+	} else {
+		gen.lastMs = ms
+		// the highest bit stays 0, leaves room for the counter
+		gen.counter = uint16(id[6]&0x07)<<8 | uint16(id[7])
+	}
+
+	ms = gen.lastMs
+	counter := gen.counter
+
+	gen.lock.Unlock()
+
+	id[0] = byte(ms >> 40)
+	id[1] = byte(ms >> 32)
+	id[2] = byte(ms >> 24)
+	id[3] = byte(ms >> 16)
+	id[4] = byte(ms >> 8)
+	id[5] = byte(ms)
+	id[6] = 0x70 | byte(counter>>8)
+	id[7] = byte(counter)
+	id[8] = 0x80 | (id[8] & 0x3f)
+
+	return formatUUID(id), nil
+}
+
+func formatUUID(id [16]byte) string {
+	buf := make([]byte, 36)
+
+	hex.Encode(buf[0:8], id[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], id[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], id[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], id[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], id[10:])
+
+	return string(buf)
+}
+
+const (
+	snowflakeTimestampBits = 41
+	snowflakeNodeBits      = 10
+	snowflakeSequenceBits  = 12
+	// SnowflakeMaxNodeID - max node id of SnowflakeGenerator
+	SnowflakeMaxNodeID    = 1<<snowflakeNodeBits - 1
+	snowflakeMaxSequence  = 1<<snowflakeSequenceBits - 1
+	snowflakeMaxTimestamp = 1<<snowflakeTimestampBits - 1
+)
+
+// SnowflakeEpoch - default epoch of SnowflakeGenerator, 2020-01-01 00:00:00 UTC
+var SnowflakeEpoch = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// SnowflakeGenerator - 64 bits id, 41 bits milliseconds since epoch | 10 bits node id | 12 bits sequence
+type SnowflakeGenerator struct {
+	lock     sync.Mutex
+	timer    ITime
+	epoch    int64
+	nodeID   int64
+	lastMs   int64
+	sequence int64
+}
+
+// NewSnowflakeGenerator - new SnowflakeGenerator, timer is gTime if it is nil
+func NewSnowflakeGenerator(nodeID int, epoch time.Time, timer ITime) (*SnowflakeGenerator, error) {
+	if nodeID < 0 || nodeID > SnowflakeMaxNodeID {
+		Error("NewSnowflakeGenerator",
+			slog.Int("nodeID", nodeID),
+			Err(ErrInvalidNodeID))
+
+		return nil, ErrInvalidNodeID
+	}
+
+	if timer == nil {
+		timer = gTime
+	}
+
+	return &SnowflakeGenerator{
+		timer:  timer,
+		epoch:  epoch.UnixMilli(),
+		nodeID: int64(nodeID),
+		lastMs: -1,
+	}, nil
+}
+
+// New - new id, ids from one generator are strictly increasing.
+// It returns ErrIDOverflow if the clock is before the epoch, or the milliseconds since the epoch do not fit in 41 bits (about 69 years).
+func (gen *SnowflakeGenerator) New() (int64, error) {
+	gen.lock.Lock()
+	defer gen.lock.Unlock()
+
+	ms := gen.timer.Now().UnixMilli() - gen.epoch
+	if ms < 0 {
+		Error("SnowflakeGenerator.New",
+			slog.Int64("ms", ms),
+			Err(ErrIDOverflow))
+
+		return 0, ErrIDOverflow
+	}
+
+	if ms <= gen.lastMs {
+		gen.sequence++
+		if gen.sequence > snowflakeMaxSequence {
+			// sequence overflow, borrow the next millisecond
+			gen.lastMs++
+			gen.sequence = 0
+		}
+	} else {
+		gen.lastMs = ms
+		gen.sequence = 0
+	}
+
+	if gen.lastMs > snowflakeMaxTimestamp {
+		Error("SnowflakeGenerator.New",
+			slog.Int64("ms", gen.lastMs),
+			Err(ErrIDOverflow))
+
+		return 0, ErrIDOverflow
+	}
+
+	return gen.lastMs<<(snowflakeNodeBits+snowflakeSequenceBits) | gen.nodeID<<snowflakeSequenceBits | gen.sequence, nil
+}
+
+// ParseSnowflakeID - id -> time, node id, sequence
+func ParseSnowflakeID(id int64, epoch time.Time) (time.Time, int, int) {
+	ms := id >> (snowflakeNodeBits + snowflakeSequenceBits)
+	nodeID := (id >> snowflakeSequenceBits) & SnowflakeMaxNodeID
+	sequence := id & snowflakeMaxSequence
+
+	return time.UnixMilli(epoch.UnixMilli() + ms), int(nodeID), int(sequence)
+}
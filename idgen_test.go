@@ -0,0 +1,154 @@
+package goutils
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	gomock "github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_GenSecureToken(t *testing.T) {
+	token, err := GenSecureToken(32, AlphabetBase62)
+	assert.NoError(t, err)
+	assert.Equal(t, len(token), 32)
+
+	for _, c := range token {
+		assert.True(t, strings.ContainsRune(AlphabetBase62, c))
+	}
+
+	token, err = GenSecureToken(1000, "ab")
+	assert.NoError(t, err)
+	assert.Equal(t, len(token), 1000)
+	assert.Equal(t, strings.Count(token, "a")+strings.Count(token, "b"), 1000)
+
+	// 3 chars -> bytes >= 255 are rejected
+	token, err = GenSecureToken(3000, "abc")
+	assert.NoError(t, err)
+	assert.InDelta(t, strings.Count(token, "a"), 1000, 150)
+
+	_, err = GenSecureToken(8, "a")
+	assert.ErrorIs(t, err, ErrInvalidAlphabet)
+
+	token, err = GenSecureToken(0, AlphabetHex)
+	assert.NoError(t, err)
+	assert.Equal(t, token, "")
+
+	t.Logf("Test_GenSecureToken OK")
+}
+
+func Test_ULIDGenerator(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := NewMockITime(ctrl)
+	m.EXPECT().Now().Return(time.UnixMilli(1597647832000)).Times(100)
+	m.EXPECT().Now().Return(time.UnixMilli(1597647831000)).Times(10)
+	m.EXPECT().Now().Return(time.UnixMilli(1597647833000)).Times(1)
+
+	gen := NewULIDGenerator(m)
+
+	lst := []string{}
+	for i := 0; i < 111; i++ {
+		id, err := gen.New()
+		assert.NoError(t, err)
+		assert.Equal(t, len(id), 26)
+
+		lst = append(lst, id)
+	}
+
+	assert.True(t, sort.StringsAreSorted(lst))
+	assert.Equal(t, lst[0][:10], "01EFXKPGY0")
+	assert.Equal(t, lst[109][:10], "01EFXKPGY0")
+	assert.Equal(t, lst[110][:10], "01EFXKPHX8")
+
+	for i := 1; i < len(lst); i++ {
+		assert.NotEqual(t, lst[i-1], lst[i])
+	}
+
+	t.Logf("Test_ULIDGenerator OK")
+}
+
+func Test_UUIDv7Generator(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := NewMockITime(ctrl)
+	m.EXPECT().Now().Return(time.UnixMilli(1597647832000)).AnyTimes()
+
+	gen := NewUUIDv7Generator(m)
+	reg := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-7[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+	lst := []string{}
+	for i := 0; i < 5000; i++ {
+		id, err := gen.New()
+		assert.NoError(t, err)
+		assert.True(t, reg.MatchString(id), id)
+
+		lst = append(lst, id)
+	}
+
+	assert.True(t, sort.StringsAreSorted(lst))
+	assert.Equal(t, lst[0][:13], "0173fb3b-43c0")
+
+	for i := 1; i < len(lst); i++ {
+		assert.NotEqual(t, lst[i-1], lst[i])
+	}
+
+	t.Logf("Test_UUIDv7Generator OK")
+}
+
+func Test_SnowflakeGenerator(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	_, err := NewSnowflakeGenerator(SnowflakeMaxNodeID+1, SnowflakeEpoch, nil)
+	assert.ErrorIs(t, err, ErrInvalidNodeID)
+
+	m := NewMockITime(ctrl)
+	m.EXPECT().Now().Return(SnowflakeEpoch.Add(time.Second)).AnyTimes()
+
+	gen, err := NewSnowflakeGenerator(7, SnowflakeEpoch, m)
+	assert.NoError(t, err)
+
+	id0, err := gen.New()
+	assert.NoError(t, err)
+	ts, node, seq := ParseSnowflakeID(id0, SnowflakeEpoch)
+	assert.Equal(t, ts.UnixMilli(), SnowflakeEpoch.Add(time.Second).UnixMilli())
+	assert.Equal(t, node, 7)
+	assert.Equal(t, seq, 0)
+
+	last := id0
+	for i := 0; i < 5000; i++ {
+		id, err := gen.New()
+		assert.NoError(t, err)
+		assert.True(t, id > last)
+
+		last = id
+	}
+
+	// 4096 ids per millisecond, so the sequence overflowed once
+	ts, node, seq = ParseSnowflakeID(last, SnowflakeEpoch)
+	assert.Equal(t, ts.UnixMilli(), SnowflakeEpoch.Add(time.Second).UnixMilli()+1)
+	assert.Equal(t, node, 7)
+	assert.Equal(t, seq, 5000-4096)
+
+	// the clock is before the epoch
+	gen, err = NewSnowflakeGenerator(7, SnowflakeEpoch.Add(time.Hour), m)
+	assert.NoError(t, err)
+
+	_, err = gen.New()
+	assert.ErrorIs(t, err, ErrIDOverflow)
+
+	// 41 bits milliseconds overflow
+	gen, err = NewSnowflakeGenerator(7, SnowflakeEpoch.Add(-time.Duration(snowflakeMaxTimestamp+1)*time.Millisecond), m)
+	assert.NoError(t, err)
+
+	_, err = gen.New()
+	assert.ErrorIs(t, err, ErrIDOverflow)
+
+	t.Logf("Test_SnowflakeGenerator OK")
+}
@@ -304,9 +304,8 @@ func GetJsonIntArr(data []byte, keys ...string) ([]int, error) {
 
 		cv, err2 := GetJsonArrayEachInt(value1, dataType1, offset1, err1)
 		if err2 != nil {
-			Error("GetJsonIntArr:ArrayEach:func:GetJsonArrayEachInt",
-				slog.Int("offset", offset1),
-				Err(err2))
+			LogError("GetJsonIntArr:ArrayEach:func:GetJsonArrayEachInt", err2,
+				slog.Int("offset", offset1))
 
 			return
 		}
@@ -315,9 +314,8 @@ func GetJsonIntArr(data []byte, keys ...string) ([]int, error) {
 	}, keys...)
 	if err != nil {
 		if err != jsonparser.KeyPathNotFoundError {
-			Error("GetJsonIntArr:ArrayEach",
-				slog.Int("offset", offset),
-				Err(err))
+			LogError("GetJsonIntArr:ArrayEach", err,
+				slog.Int("offset", offset))
 
 			return nil, err
 		}
@@ -347,9 +345,8 @@ func GetJsonInt64Arr(data []byte, keys ...string) ([]int64, error) {
 
 		cv, err2 := GetJsonArrayEachInt(value1, dataType1, offset1, err1)
 		if err2 != nil {
-			Error("GetJsonInt64Arr:ArrayEach:func:GetJsonArrayEachInt",
-				slog.Int("offset", offset1),
-				Err(err2))
+			LogError("GetJsonInt64Arr:ArrayEach:func:GetJsonArrayEachInt", err2,
+				slog.Int("offset", offset1))
 
 			return
 		}
@@ -358,9 +355,8 @@ func GetJsonInt64Arr(data []byte, keys ...string) ([]int64, error) {
 	}, keys...)
 	if err != nil {
 		if err != jsonparser.KeyPathNotFoundError {
-			Error("GetJsonInt64Arr:ArrayEach",
-				slog.Int("offset", offset),
-				Err(err))
+			LogError("GetJsonInt64Arr:ArrayEach", err,
+				slog.Int("offset", offset))
 
 			return nil, err
 		}
@@ -431,9 +427,8 @@ func GetJsonIntArr2(data []byte, keys ...string) ([][]int, error) {
 
 				cv, err5 := GetJsonArrayEachInt(value2, dataType2, offset2, err2)
 				if err5 != nil {
-					Error("GetJsonIntArr2:ArrayEach:func2:GetJsonArrayEachInt",
-						slog.Int("offset", offset2),
-						Err(err2))
+					LogError("GetJsonIntArr2:ArrayEach:func2:GetJsonArrayEachInt", err5,
+						slog.Int("offset", offset2))
 
 					return
 				}
@@ -505,9 +500,8 @@ func GetJsonInt64Arr2(data []byte, keys ...string) ([][]int64, error) {
 
 				cv, err5 := GetJsonArrayEachInt(value2, dataType2, offset2, err2)
 				if err5 != nil {
-					Error("GetJsonInt64Arr2:ArrayEach:func2:GetJsonArrayEachInt",
-						slog.Int("offset", offset2),
-						Err(err5))
+					LogError("GetJsonInt64Arr2:ArrayEach:func2:GetJsonArrayEachInt", err5,
+						slog.Int("offset", offset2))
 
 					return
 				}
@@ -593,9 +587,8 @@ func GetJsonIntArr3(data []byte, keys ...string) ([][][]int, error) {
 
 						cv, err7 := GetJsonArrayEachInt(value5, dataType5, offset5, err5)
 						if err7 != nil {
-							Error("GetJsonIntArr3:ArrayEach:func3:GetJsonArrayEachInt",
-								slog.Int("offset", offset5),
-								Err(err5))
+							LogError("GetJsonIntArr3:ArrayEach:func3:GetJsonArrayEachInt", err7,
+								slog.Int("offset", offset5))
 
 							return
 						}
@@ -660,7 +653,7 @@ func GetJsonInt64Arr3(data []byte, keys ...string) ([][][]int64, error) {
 	offset, err := jsonparser.ArrayEach(data, func(value []byte, dataType jsonparser.ValueType, offset int, err error) {
 		if err != nil {
 			if err != jsonparser.KeyPathNotFoundError {
-				Error("GetJsonIntArr3:ArrayEach:func",
+				Error("GetJsonInt64Arr3:ArrayEach:func",
 					slog.Int("offset", offset),
 					Err(err))
 
@@ -674,7 +667,7 @@ func GetJsonInt64Arr3(data []byte, keys ...string) ([][][]int64, error) {
 			offset3, err3 := jsonparser.ArrayEach(value, func(value2 []byte, dataType2 jsonparser.ValueType, offset2 int, err2 error) {
 				if err2 != nil {
 					if err != jsonparser.KeyPathNotFoundError {
-						Error("GetJsonIntArr3:ArrayEach:func2",
+						Error("GetJsonInt64Arr3:ArrayEach:func2",
 							slog.Int("offset", offset2),
 							Err(err2))
 
@@ -688,7 +681,7 @@ func GetJsonInt64Arr3(data []byte, keys ...string) ([][][]int64, error) {
 					offset6, err6 := jsonparser.ArrayEach(value2, func(value5 []byte, dataType5 jsonparser.ValueType, offset5 int, err5 error) {
 						if err5 != nil {
 							if err != jsonparser.KeyPathNotFoundError {
-								Error("GetJsonIntArr3:ArrayEach:func3",
+								Error("GetJsonInt64Arr3:ArrayEach:func3",
 									slog.Int("offset", offset5),
 									Err(err5))
 
@@ -698,9 +691,8 @@ func GetJsonInt64Arr3(data []byte, keys ...string) ([][][]int64, error) {
 
 						cv, err7 := GetJsonArrayEachInt(value5, dataType5, offset5, err5)
 						if err7 != nil {
-							Error("GetJsonIntArr3:ArrayEach:func3:GetJsonArrayEachInt",
-								slog.Int("offset", offset5),
-								Err(err5))
+							LogError("GetJsonInt64Arr3:ArrayEach:func3:GetJsonArrayEachInt", err7,
+								slog.Int("offset", offset5))
 
 							return
 						}
@@ -708,7 +700,7 @@ func GetJsonInt64Arr3(data []byte, keys ...string) ([][][]int64, error) {
 						arr1 = append(arr1, cv)
 					})
 					if err6 != nil {
-						Error("GetJsonIntArr3:ArrayEach:func2:ArrayEach",
+						Error("GetJsonInt64Arr3:ArrayEach:func2:ArrayEach",
 							slog.Int("offset", offset6),
 							Err(err6))
 
@@ -720,12 +712,12 @@ func GetJsonInt64Arr3(data []byte, keys ...string) ([][][]int64, error) {
 					return
 				}
 
-				Error("GetJsonIntArr3:ArrayEach:func2:dataType",
+				Error("GetJsonInt64Arr3:ArrayEach:func2:dataType",
 					slog.Int("offset", offset2),
 					slog.String("dataType", dataType2.String()))
 			})
 			if err3 != nil {
-				Error("GetJsonIntArr3:ArrayEach:func:ArrayEach",
+				Error("GetJsonInt64Arr3:ArrayEach:func:ArrayEach",
 					slog.Int("offset", offset3),
 					Err(err3))
 
@@ -737,13 +729,13 @@ func GetJsonInt64Arr3(data []byte, keys ...string) ([][][]int64, error) {
 			return
 		}
 
-		Error("GetJsonIntArr3:ArrayEach:func:dataType",
+		Error("GetJsonInt64Arr3:ArrayEach:func:dataType",
 			slog.Int("offset", offset),
 			slog.String("dataType", dataType.String()))
 	}, keys...)
 	if err != nil {
 		if err != jsonparser.KeyPathNotFoundError {
-			Error("GetJsonIntArr3:ArrayEach",
+			Error("GetJsonInt64Arr3:ArrayEach",
 				slog.Any("keys", keys),
 				slog.Int("offset", offset),
 				Err(err))
@@ -0,0 +1,270 @@
+package goutils
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+)
+
+// MapWeightsVerifyNode - observed vs expected result for one name
+type MapWeightsVerifyNode struct {
+	Name              string  `json:"name"`
+	Weight            int     `json:"weight"`
+	ExpectedProb      float64 `json:"expectedProb"`
+	ExpectedTimes     float64 `json:"expectedTimes"`
+	ObservedTimes     int     `json:"observedTimes"`
+	ObservedProb      float64 `json:"observedProb"`
+	ChiSquareFraction float64 `json:"chiSquareFraction"`
+}
+
+// MapWeightsVerifyReport - result of MapWeights.Verify
+type MapWeightsVerifyReport struct {
+	Samples          int                     `json:"samples"`
+	TotalWeight      int                     `json:"totalWeight"`
+	Nodes            []*MapWeightsVerifyNode `json:"nodes"`
+	ChiSquare        float64                 `json:"chiSquare"`
+	DegreesOfFreedom int                     `json:"degreesOfFreedom"`
+	PValue           float64                 `json:"pValue"`
+}
+
+// IsPassed - the goodness-of-fit test is not rejected at significance level alpha (e.g. 0.01)
+func (report *MapWeightsVerifyReport) IsPassed(alpha float64) bool {
+	return report.PValue >= alpha
+}
+
+// String - one line summary
+func (report *MapWeightsVerifyReport) String() string {
+	return fmt.Sprintf("samples=%v chiSquare=%v df=%v pValue=%v", report.Samples, report.ChiSquare, report.DegreesOfFreedom, report.PValue)
+}
+
+// OutputCSV - write the report as csv, one row per name,
+// the summary (samples, chiSquare, degreesOfFreedom, pValue) is repeated in the last columns of every row
+func (report *MapWeightsVerifyReport) OutputCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+
+	err := writer.Write([]string{"name", "weight", "expectedProb", "expectedTimes", "observedTimes", "observedProb", "chiSquareFraction",
+		"samples", "chiSquare", "degreesOfFreedom", "pValue"})
+	if err != nil {
+		Error("MapWeightsVerifyReport.OutputCSV:Write:header",
+			Err(err))
+
+		return err
+	}
+
+	summary := []string{
+		strconv.Itoa(report.Samples),
+		strconv.FormatFloat(report.ChiSquare, 'f', -1, 64),
+		strconv.Itoa(report.DegreesOfFreedom),
+		strconv.FormatFloat(report.PValue, 'f', -1, 64),
+	}
+
+	for _, node := range report.Nodes {
+		err = writer.Write(append([]string{
+			node.Name,
+			strconv.Itoa(node.Weight),
+			strconv.FormatFloat(node.ExpectedProb, 'f', -1, 64),
+			strconv.FormatFloat(node.ExpectedTimes, 'f', -1, 64),
+			strconv.Itoa(node.ObservedTimes),
+			strconv.FormatFloat(node.ObservedProb, 'f', -1, 64),
+			strconv.FormatFloat(node.ChiSquareFraction, 'f', -1, 64),
+		}, summary...))
+		if err != nil {
+			Error("MapWeightsVerifyReport.OutputCSV:Write:node",
+				slog.String("name", node.Name),
+				Err(err))
+
+			return err
+		}
+	}
+
+	writer.Flush()
+
+	return writer.Error()
+}
+
+// SaveCSV - save the report into a csv file
+func (report *MapWeightsVerifyReport) SaveCSV(fn string) error {
+	f, err := os.Create(fn)
+	if err != nil {
+		Error("MapWeightsVerifyReport.SaveCSV:Create",
+			slog.String("fn", fn),
+			Err(err))
+
+		return err
+	}
+	defer f.Close()
+
+	return report.OutputCSV(f)
+}
+
+// Verify - draw samples times with Rand, and run a chi-square goodness-of-fit test against the weights
+func (mapWeights *MapWeights) Verify(samples int) (*MapWeightsVerifyReport, error) {
+	return mapWeights.VerifyWith(samples, mapWeights.Rand)
+}
+
+// VerifyWith - like Verify, but draws with funcRand, so a custom random source can be checked
+func (mapWeights *MapWeights) VerifyWith(samples int, funcRand func() string) (*MapWeightsVerifyReport, error) {
+	if samples <= 0 {
+		Error("MapWeights.VerifyWith",
+			slog.Int("samples", samples),
+			Err(ErrInvalidSamples))
+
+		return nil, ErrInvalidSamples
+	}
+
+	if mapWeights.TotalWeight <= 0 || len(mapWeights.MapWeights) == 0 {
+		Error("MapWeights.VerifyWith",
+			slog.Int("totalWeight", mapWeights.TotalWeight),
+			Err(ErrEmptyMapWeights))
+
+		return nil, ErrEmptyMapWeights
+	}
+
+	observed := make(map[string]int)
+	for i := 0; i < samples; i++ {
+		observed[funcRand()]++
+	}
+
+	report := &MapWeightsVerifyReport{
+		Samples:     samples,
+		TotalWeight: mapWeights.TotalWeight,
+	}
+
+	for name, weight := range mapWeights.MapWeights {
+		node := &MapWeightsVerifyNode{
+			Name:          name,
+			Weight:        weight,
+			ExpectedProb:  float64(weight) / float64(mapWeights.TotalWeight),
+			ObservedTimes: observed[name],
+		}
+
+		node.ExpectedTimes = node.ExpectedProb * float64(samples)
+		node.ObservedProb = float64(node.ObservedTimes) / float64(samples)
+
+		if node.ExpectedTimes > 0 {
+			diff := float64(node.ObservedTimes) - node.ExpectedTimes
+			node.ChiSquareFraction = diff * diff / node.ExpectedTimes
+
+			report.ChiSquare += node.ChiSquareFraction
+			report.DegreesOfFreedom++
+		} else if node.ObservedTimes > 0 {
+			// a name with zero weight must never be drawn
+			node.ChiSquareFraction = math.Inf(1)
+			report.ChiSquare = math.Inf(1)
+		}
+
+		report.Nodes = append(report.Nodes, node)
+	}
+
+	for name, times := range observed {
+		_, isok := mapWeights.MapWeights[name]
+		if !isok {
+			report.Nodes = append(report.Nodes, &MapWeightsVerifyNode{
+				Name:              name,
+				ObservedTimes:     times,
+				ObservedProb:      float64(times) / float64(samples),
+				ChiSquareFraction: math.Inf(1),
+			})
+
+			report.ChiSquare = math.Inf(1)
+		}
+	}
+
+	sort.Slice(report.Nodes, func(i, j int) bool {
+		return report.Nodes[i].Name < report.Nodes[j].Name
+	})
+
+	// k categories -> k - 1 degrees of freedom
+	report.DegreesOfFreedom--
+
+	report.PValue = ChiSquarePValue(report.ChiSquare, report.DegreesOfFreedom)
+
+	return report, nil
+}
+
+// ChiSquarePValue - P(X >= chi2) for a chi-square distribution with df degrees of freedom
+func ChiSquarePValue(chi2 float64, df int) float64 {
+	if math.IsInf(chi2, 1) || math.IsNaN(chi2) {
+		return 0
+	}
+
+	if df <= 0 {
+		if chi2 > 0 {
+			return 0
+		}
+
+		return 1
+	}
+
+	if chi2 <= 0 {
+		return 1
+	}
+
+	return regularizedGammaQ(float64(df)/2, chi2/2)
+}
+
+// regularizedGammaQ - Q(a, x) = 1 - P(a, x), the regularized upper incomplete gamma function
+func regularizedGammaQ(a float64, x float64) float64 {
+	const maxIterations = 1000
+	const eps = 1e-15
+
+	lga, _ := math.Lgamma(a)
+	lnPrefix := a*math.Log(x) - x - lga
+
+	if x < a+1 {
+		// series representation of P(a, x)
+		sum := 1 / a
+		del := sum
+		ap := a
+
+		for i := 0; i < maxIterations; i++ {
+			ap++
+			del *= x / ap
+			sum += del
+
+			if math.Abs(del) < math.Abs(sum)*eps {
+				break
+			}
+		}
+
+		return 1 - sum*math.Exp(lnPrefix)
+	}
+
+	// continued fraction representation of Q(a, x), modified Lentz's method
+	const tiny = 1e-300
+
+	b := x + 1 - a
+	c := 1 / tiny
+	d := 1 / b
+	h := d
+
+	for i := 1; i <= maxIterations; i++ {
+		an := -float64(i) * (float64(i) - a)
+		b += 2
+
+		d = an*d + b
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+
+		c = b + an/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+
+		d = 1 / d
+		del := d * c
+		h *= del
+
+		if math.Abs(del-1) < eps {
+			break
+		}
+	}
+
+	return math.Exp(lnPrefix) * h
+}
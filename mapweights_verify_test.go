@@ -0,0 +1,82 @@
+package goutils
+
+import (
+	"bytes"
+	"encoding/csv"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ChiSquarePValue(t *testing.T) {
+	// reference values from the chi-square table
+	assert.InDelta(t, ChiSquarePValue(3.841, 1), 0.05, 0.0005)
+	assert.InDelta(t, ChiSquarePValue(5.991, 2), 0.05, 0.0005)
+	assert.InDelta(t, ChiSquarePValue(11.345, 3), 0.01, 0.0005)
+	assert.InDelta(t, ChiSquarePValue(18.307, 10), 0.05, 0.0005)
+	assert.InDelta(t, ChiSquarePValue(0.5, 4), 0.9735, 0.0005)
+
+	assert.Equal(t, ChiSquarePValue(0, 3), 1.0)
+	assert.Equal(t, ChiSquarePValue(math.Inf(1), 3), 0.0)
+
+	t.Logf("Test_ChiSquarePValue OK")
+}
+
+func Test_MapWeightsVerify(t *testing.T) {
+	mw := NewMapWeights()
+	assert.NoError(t, mw.AddWeight("a", 10, true))
+	assert.NoError(t, mw.AddWeight("b", 30, false))
+	assert.NoError(t, mw.AddWeight("c", 60, false))
+
+	report, err := mw.Verify(100000)
+	assert.NoError(t, err)
+	assert.Equal(t, report.Samples, 100000)
+	assert.Equal(t, report.DegreesOfFreedom, 2)
+	assert.Equal(t, len(report.Nodes), 3)
+	assert.Equal(t, report.Nodes[0].Name, "a")
+	assert.InDelta(t, report.Nodes[0].ExpectedProb, 0.1, 1e-9)
+	assert.InDelta(t, report.Nodes[2].ObservedProb, 0.6, 0.01)
+	assert.True(t, report.IsPassed(0.0001))
+
+	// a biased source must be rejected
+	i := 0
+	report, err = mw.VerifyWith(10000, func() string {
+		i++
+		if i%2 == 0 {
+			return "a"
+		}
+
+		return "c"
+	})
+	assert.NoError(t, err)
+	assert.False(t, report.IsPassed(0.01))
+
+	// a name not in the table must be rejected
+	report, err = mw.VerifyWith(100, func() string {
+		return "d"
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, report.PValue, 0.0)
+	assert.Equal(t, len(report.Nodes), 4)
+
+	buf := &bytes.Buffer{}
+	err = report.OutputCSV(buf)
+	assert.NoError(t, err)
+
+	records, err := csv.NewReader(buf).ReadAll()
+	assert.NoError(t, err)
+	assert.Equal(t, len(records), 5)
+	assert.Equal(t, records[0], []string{"name", "weight", "expectedProb", "expectedTimes", "observedTimes", "observedProb", "chiSquareFraction",
+		"samples", "chiSquare", "degreesOfFreedom", "pValue"})
+	assert.Equal(t, records[4][0], "d")
+	assert.Equal(t, records[4][7:], []string{"100", "+Inf", "2", "0"})
+
+	_, err = mw.Verify(0)
+	assert.ErrorIs(t, err, ErrInvalidSamples)
+
+	_, err = NewMapWeights().Verify(100)
+	assert.ErrorIs(t, err, ErrEmptyMapWeights)
+
+	t.Logf("Test_MapWeightsVerify OK")
+}
@@ -0,0 +1,368 @@
+package goutils
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultPromLatencyBuckets - default le buckets of latency histograms, in seconds
+var DefaultPromLatencyBuckets = []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// DefaultPromSizeBuckets - default le buckets of size histograms, in bytes
+var DefaultPromSizeBuckets = []float64{64, 256, 1024, 4096, 16384, 65536, 262144, 1048576}
+
+const promContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+type promSample struct {
+	suffix string
+	labels []string
+	value  float64
+}
+
+type promFamily struct {
+	name    string
+	help    string
+	typ     string
+	samples []*promSample
+}
+
+// promWriter - collects samples by family, every family is written once with HELP and TYPE
+type promWriter struct {
+	constLabels []string
+	families    map[string]*promFamily
+	names       []string
+}
+
+func newPromWriter(constLabels []string) *promWriter {
+	return &promWriter{
+		constLabels: constLabels,
+		families:    make(map[string]*promFamily),
+	}
+}
+
+func (pw *promWriter) family(name string, typ string, help string) *promFamily {
+	f, isok := pw.families[name]
+	if !isok {
+		f = &promFamily{
+			name: name,
+			help: help,
+			typ:  typ,
+		}
+
+		pw.families[name] = f
+		pw.names = append(pw.names, name)
+	}
+
+	return f
+}
+
+// add - labels is key0, value0, key1, value1, ...
+func (pw *promWriter) add(name string, typ string, help string, value float64, labels ...string) {
+	f := pw.family(name, typ, help)
+	f.samples = append(f.samples, &promSample{
+		labels: labels,
+		value:  value,
+	})
+}
+
+// addHistogram - le buckets are computed from the log-bucketed Histogram
+func (pw *promWriter) addHistogram(name string, help string, h *Histogram, buckets []float64, labels ...string) {
+	f := pw.family(name, "histogram", help)
+
+	for _, le := range buckets {
+		f.samples = append(f.samples, &promSample{
+			suffix: "_bucket",
+			labels: append(append([]string{}, labels...), "le", formatPromFloat(le)),
+			value:  float64(h.CountLessOrEqual(le)),
+		})
+	}
+
+	f.samples = append(f.samples, &promSample{
+		suffix: "_bucket",
+		labels: append(append([]string{}, labels...), "le", "+Inf"),
+		value:  float64(h.Count),
+	}, &promSample{
+		suffix: "_sum",
+		labels: labels,
+		value:  h.Sum,
+	}, &promSample{
+		suffix: "_count",
+		labels: labels,
+		value:  float64(h.Count),
+	})
+}
+
+func (pw *promWriter) writeTo(w io.Writer) error {
+	buf := &bytes.Buffer{}
+
+	for _, name := range pw.names {
+		f := pw.families[name]
+
+		fmt.Fprintf(buf, "# HELP %v %v\n", f.name, escapePromHelp(f.help))
+		fmt.Fprintf(buf, "# TYPE %v %v\n", f.name, f.typ)
+
+		for _, s := range f.samples {
+			buf.WriteString(f.name)
+			buf.WriteString(s.suffix)
+
+			labels := append(append([]string{}, pw.constLabels...), s.labels...)
+			if len(labels) > 0 {
+				buf.WriteByte('{')
+				for i := 0; i+1 < len(labels); i += 2 {
+					if i > 0 {
+						buf.WriteByte(',')
+					}
+
+					buf.WriteString(labels[i])
+					buf.WriteString(`="`)
+					buf.WriteString(escapePromLabelValue(labels[i+1]))
+					buf.WriteByte('"')
+				}
+				buf.WriteByte('}')
+			}
+
+			buf.WriteByte(' ')
+			buf.WriteString(formatPromFloat(s.value))
+			buf.WriteByte('\n')
+		}
+	}
+
+	_, err := w.Write(buf.Bytes())
+
+	return err
+}
+
+func formatPromFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func escapePromHelp(str string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(str)
+}
+
+func escapePromLabelValue(str string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`).Replace(str)
+}
+
+// sanitizePromName - invalid chars are replaced with '_'
+func sanitizePromName(str string) string {
+	buf := []byte(str)
+	for i, c := range buf {
+		isValid := c == '_' || c == ':' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (i > 0 && c >= '0' && c <= '9')
+		if !isValid {
+			buf[i] = '_'
+		}
+	}
+
+	return string(buf)
+}
+
+// PromHandler - http.Handler, renders ServStats, SenderStats and RuntimeStats in Prometheus text exposition format.
+// The counters and histograms keep growing with ResetOnOutput, they include the counters cleared by every Output.
+type PromHandler struct {
+	Prefix         string
+	ConstLabels    map[string]string
+	LatencyBuckets []float64
+	SizeBuckets    []float64
+	lstServStats   []*ServStats
+	lstSender      []*SenderStats
+	lstRuntime     []*RuntimeStats
+	lock           sync.Mutex
+}
+
+// NewPromHandler - new PromHandler, prefix is like "gameserv", constLabels is added to every sample
+func NewPromHandler(prefix string, constLabels map[string]string) *PromHandler {
+	return &PromHandler{
+		Prefix:         prefix,
+		ConstLabels:    constLabels,
+		LatencyBuckets: DefaultPromLatencyBuckets,
+		SizeBuckets:    DefaultPromSizeBuckets,
+	}
+}
+
+// AddServStats - add a ServStats, its samples are labeled with stats=prefixFN
+func (handler *PromHandler) AddServStats(stats *ServStats) {
+	handler.lock.Lock()
+	handler.lstServStats = append(handler.lstServStats, stats)
+	handler.lock.Unlock()
+}
+
+// AddSenderStats - add a SenderStats, its samples are labeled with stats=prefixFN
+func (handler *PromHandler) AddSenderStats(stats *SenderStats) {
+	handler.lock.Lock()
+	handler.lstSender = append(handler.lstSender, stats)
+	handler.lock.Unlock()
+}
+
+// AddRuntimeStats - add a RuntimeStats, its samples are labeled with stats=prefixFN
+func (handler *PromHandler) AddRuntimeStats(stats *RuntimeStats) {
+	handler.lock.Lock()
+	handler.lstRuntime = append(handler.lstRuntime, stats)
+	handler.lock.Unlock()
+}
+
+func (handler *PromHandler) metricName(name string) string {
+	if handler.Prefix == "" {
+		return sanitizePromName(name)
+	}
+
+	return sanitizePromName(handler.Prefix + "_" + name)
+}
+
+func (handler *PromHandler) buildConstLabels() []string {
+	keys := make([]string, 0, len(handler.ConstLabels))
+	for k := range handler.ConstLabels {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	labels := []string{}
+	for _, k := range keys {
+		labels = append(labels, sanitizePromName(k), handler.ConstLabels[k])
+	}
+
+	return labels
+}
+
+func (handler *PromHandler) collectServStats(pw *promWriter, stats *ServStats) {
+	for _, msg := range stats.sortedMsgs() {
+		msg.lock.Lock()
+
+		labels := []string{"stats", stats.prefixFN, "name", msg.Name}
+		totalTimes, histogram, mapOutcomes := msg.cumulativeCounters()
+
+		pw.add(handler.metricName("servstats_requests_total"), "counter",
+			"Total number of finished messages.", float64(totalTimes), labels...)
+		pw.add(handler.metricName("servstats_inflight"), "gauge",
+			"Number of messages in processing.", float64(msg.LastMsgNums), labels...)
+		pw.add(handler.metricName("servstats_max_parallels"), "gauge",
+			"Max number of messages in processing at the same time.", float64(msg.MaxParallels), labels...)
+		pw.addHistogram(handler.metricName("servstats_latency_seconds"),
+			"Latency of messages in seconds.", histogram, handler.LatencyBuckets, labels...)
+
+		outcomes := make([]string, 0, len(mapOutcomes))
+		for k := range mapOutcomes {
+			outcomes = append(outcomes, k)
+		}
+
+		sort.Strings(outcomes)
+
+		for _, k := range outcomes {
+			o := mapOutcomes[k]
+			olabels := append(append([]string{}, labels...), "outcome", k)
+
+			pw.add(handler.metricName("servstats_outcomes_total"), "counter",
+				"Total number of finished messages by outcome.", float64(o.Times), olabels...)
+			pw.addHistogram(handler.metricName("servstats_outcome_latency_seconds"),
+				"Latency of messages by outcome in seconds.", o.Histogram, handler.LatencyBuckets, olabels...)
+		}
+
+		msg.lock.Unlock()
+	}
+}
+
+func (handler *PromHandler) collectSenderStats(pw *promWriter, stats *SenderStats) {
+	stats.lock.Lock()
+	defer stats.lock.Unlock()
+
+	stats.collect(stats.timer.Now(), false)
+
+	names := make([]string, 0, len(stats.MapNodes))
+	for k := range stats.MapNodes {
+		names = append(names, k)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		node := stats.MapNodes[name]
+		labels := []string{"stats", stats.prefixFN, "name", node.Name}
+
+		totalTimes, totalBytes, histogram := int64(node.TotalTimes), node.TotalBytes, node.Histogram
+		if v, isok := stats.counters.Load(name); isok {
+			totalTimes, totalBytes, histogram = v.(*senderStatsCounter).cumulativeCounters(node)
+		}
+
+		pw.add(handler.metricName("senderstats_bytes_total"), "counter",
+			"Total number of sent bytes.", float64(totalBytes), labels...)
+		pw.add(handler.metricName("senderstats_messages_total"), "counter",
+			"Total number of sent messages.", float64(totalTimes), labels...)
+		pw.addHistogram(handler.metricName("senderstats_size_bytes"),
+			"Size of sent messages in bytes.", histogram, handler.SizeBuckets, labels...)
+	}
+}
+
+// collectRuntimeStats - the last sample is <name>, min / max / avg of the current interval are <name>_min / _max / _avg
+func (handler *PromHandler) collectRuntimeStats(pw *promWriter, stats *RuntimeStats) {
+	stats.lock.Lock()
+	defer stats.lock.Unlock()
+
+	labels := []string{"stats", stats.prefixFN}
+
+	for _, desc := range runtimeStatsDescs {
+		m, isok := stats.Metrics[desc.name]
+		if !isok {
+			continue
+		}
+
+		m = m.clone()
+		name := handler.metricName("runtime_" + desc.promName)
+
+		pw.add(name, "gauge", desc.help, m.Last, labels...)
+		pw.add(name+"_min", "gauge", "Min of "+desc.promName+" in the current interval.", m.Min, labels...)
+		pw.add(name+"_max", "gauge", "Max of "+desc.promName+" in the current interval.", m.Max, labels...)
+		pw.add(name+"_avg", "gauge", "Avg of "+desc.promName+" in the current interval.", m.Avg, labels...)
+	}
+}
+
+// Render - write all samples in Prometheus text exposition format
+func (handler *PromHandler) Render(w io.Writer) error {
+	handler.lock.Lock()
+	lstServStats := append([]*ServStats{}, handler.lstServStats...)
+	lstSender := append([]*SenderStats{}, handler.lstSender...)
+	lstRuntime := append([]*RuntimeStats{}, handler.lstRuntime...)
+	handler.lock.Unlock()
+
+	pw := newPromWriter(handler.buildConstLabels())
+
+	for _, stats := range lstServStats {
+		handler.collectServStats(pw, stats)
+	}
+
+	for _, stats := range lstSender {
+		handler.collectSenderStats(pw, stats)
+	}
+
+	for _, stats := range lstRuntime {
+		handler.collectRuntimeStats(pw, stats)
+	}
+
+	return pw.writeTo(w)
+}
+
+// ServeHTTP - http.Handler
+func (handler *PromHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	buf := &bytes.Buffer{}
+
+	err := handler.Render(buf)
+	if err != nil {
+		Warn("PromHandler.ServeHTTP:Render",
+			slog.String("url", r.URL.String()),
+			Err(err))
+
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", promContentType)
+	w.Write(buf.Bytes())
+}
@@ -0,0 +1,102 @@
+package goutils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_PromHandler(t *testing.T) {
+	servstats := NewServStats(10, 0, time.Hour, "./", 16, "serv")
+	servstats.RegMsg("login")
+	servstats.RegMsg("spin")
+
+	for i := 0; i < 10; i++ {
+		node := servstats.StartMsg("login")
+		node.Start = time.Now().Add(-20 * time.Millisecond)
+		servstats.EndMsg("login", node)
+	}
+
+	servstats.StartMsg("spin")
+
+	senderstats := NewSenderStats(10, 0, time.Hour, "./", "sender")
+	senderstats.Push("gamestate", 100)
+	senderstats.Push("gamestate", 2000)
+
+	handler := NewPromHandler("game", map[string]string{"app": "slots\"1"})
+	handler.AddServStats(servstats)
+	handler.AddSenderStats(senderstats)
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.Equal(t, rec.Header().Get("Content-Type"), promContentType)
+
+	body := rec.Body.String()
+	assert.Equal(t, strings.Count(body, "# TYPE game_servstats_requests_total counter"), 1)
+	assert.Contains(t, body, `game_servstats_requests_total{app="slots\"1",stats="serv",name="login"} 10`)
+	assert.Contains(t, body, `game_servstats_requests_total{app="slots\"1",stats="serv",name="spin"} 0`)
+	assert.Contains(t, body, `game_servstats_inflight{app="slots\"1",stats="serv",name="spin"} 1`)
+	assert.Contains(t, body, `game_servstats_latency_seconds_bucket{app="slots\"1",stats="serv",name="login",le="0.01"} 0`)
+	assert.Contains(t, body, `game_servstats_latency_seconds_bucket{app="slots\"1",stats="serv",name="login",le="0.1"} 10`)
+	assert.Contains(t, body, `game_servstats_latency_seconds_count{app="slots\"1",stats="serv",name="login"} 10`)
+	assert.Contains(t, body, `game_senderstats_bytes_total{app="slots\"1",stats="sender",name="gamestate"} 2100`)
+	assert.Contains(t, body, `game_senderstats_messages_total{app="slots\"1",stats="sender",name="gamestate"} 2`)
+	assert.Contains(t, body, `game_senderstats_size_bytes_bucket{app="slots\"1",stats="sender",name="gamestate",le="256"} 1`)
+	assert.Contains(t, body, `game_senderstats_size_bytes_bucket{app="slots\"1",stats="sender",name="gamestate",le="+Inf"} 2`)
+
+	assert.Equal(t, sanitizePromName("0a-b.c"), "_a_b_c")
+
+	t.Logf("Test_PromHandler OK")
+}
+
+func Test_PromHandlerResetOnOutput(t *testing.T) {
+	dir := t.TempDir()
+
+	servstats := NewServStats(10, 0, time.Hour, dir, 16, "serv")
+	servstats.SetResetOnOutput(true)
+	servstats.RegMsg("login")
+
+	senderstats := NewSenderStats(10, 0, time.Hour, dir, "sender")
+	senderstats.SetResetOnOutput(true)
+
+	handler := NewPromHandler("game", nil)
+	handler.AddServStats(servstats)
+	handler.AddSenderStats(senderstats)
+
+	for i := 0; i < 3; i++ {
+		servstats.EndMsg("login", servstats.StartMsg("login"))
+		servstats.EndMsgWithErr("login", servstats.StartMsg("login"), ErrHTTPServerError)
+		senderstats.Push("gamestate", 100)
+
+		servstats.Output()
+		senderstats.Output()
+	}
+
+	servstats.EndMsg("login", servstats.StartMsg("login"))
+	senderstats.Push("gamestate", 100)
+
+	// the counters keep growing after every reset
+	buf := &strings.Builder{}
+	assert.NoError(t, handler.Render(buf))
+
+	body := buf.String()
+	assert.Contains(t, body, `game_servstats_requests_total{stats="serv",name="login"} 7`+"\n")
+	assert.Contains(t, body, `game_servstats_latency_seconds_count{stats="serv",name="login"} 7`+"\n")
+	assert.Contains(t, body, `game_servstats_outcomes_total{stats="serv",name="login",outcome="ok"} 4`+"\n")
+	assert.Contains(t, body, `game_servstats_outcomes_total{stats="serv",name="login",outcome="error"} 3`+"\n")
+	assert.Contains(t, body, `game_senderstats_bytes_total{stats="sender",name="gamestate"} 400`+"\n")
+	assert.Contains(t, body, `game_senderstats_messages_total{stats="sender",name="gamestate"} 4`+"\n")
+	assert.Contains(t, body, `game_senderstats_size_bytes_count{stats="sender",name="gamestate"} 4`+"\n")
+
+	// the outputs are still per interval
+	assert.Equal(t, servstats.MapMsgs["login"].TotalTimes, 1)
+
+	t.Logf("Test_PromHandlerResetOnOutput OK")
+}
@@ -4,7 +4,7 @@ import (
 	"math/rand"
 )
 
-// GenHashCode - generator a hash code
+// GenHashCode - generator a hash code, it is not safe for tokens, use GenSecureToken instead
 func GenHashCode(length int) string {
 	const HASHSTRING = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"
 	str := ""
@@ -0,0 +1,411 @@
+package goutils
+
+import (
+	"context"
+	"math"
+	"os"
+	"runtime/metrics"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+const (
+	// RuntimeStatsGoroutines - number of live goroutines
+	RuntimeStatsGoroutines = "goroutines"
+	// RuntimeStatsHeapObjectsBytes - bytes of live and unswept heap objects
+	RuntimeStatsHeapObjectsBytes = "heapObjectsBytes"
+	// RuntimeStatsHeapGoalBytes - heap size target of the current GC cycle
+	RuntimeStatsHeapGoalBytes = "heapGoalBytes"
+	// RuntimeStatsTotalMemoryBytes - all memory mapped by the Go runtime
+	RuntimeStatsTotalMemoryBytes = "totalMemoryBytes"
+	// RuntimeStatsGCCycles - GC cycles completed since the last sample
+	RuntimeStatsGCCycles = "gcCycles"
+	// RuntimeStatsGCPauseSeconds - every stop-the-world pause of GC
+	RuntimeStatsGCPauseSeconds = "gcPauseSeconds"
+	// RuntimeStatsFDs - number of open file descriptors, only on systems with /proc/self/fd
+	RuntimeStatsFDs = "fds"
+)
+
+// runtimeStatsDesc - a metric of RuntimeStats, samples are names in runtime/metrics, the first supported one is used
+type runtimeStatsDesc struct {
+	name     string
+	promName string
+	help     string
+	samples  []string
+}
+
+var runtimeStatsDescs = []*runtimeStatsDesc{
+	{RuntimeStatsGoroutines, "goroutines", "Number of live goroutines.", []string{"/sched/goroutines:goroutines"}},
+	{RuntimeStatsHeapObjectsBytes, "heap_objects_bytes", "Bytes of live and unswept heap objects.", []string{"/memory/classes/heap/objects:bytes"}},
+	{RuntimeStatsHeapGoalBytes, "heap_goal_bytes", "Heap size target of the current GC cycle.", []string{"/gc/heap/goal:bytes"}},
+	{RuntimeStatsTotalMemoryBytes, "total_memory_bytes", "All memory mapped by the Go runtime.", []string{"/memory/classes/total:bytes"}},
+	{RuntimeStatsGCCycles, "gc_cycles", "GC cycles completed between two samples.", []string{"/gc/cycles/total:gc-cycles"}},
+	{RuntimeStatsGCPauseSeconds, "gc_pause_seconds", "Stop-the-world pauses of GC in seconds.", []string{"/sched/pauses/total/gc:seconds", "/gc/pauses:seconds"}},
+	{RuntimeStatsFDs, "fds", "Number of open file descriptors.", nil},
+}
+
+// RuntimeStatsMetric - the samples of a metric in an output interval
+type RuntimeStatsMetric struct {
+	Name  string  `json:"name"`
+	Times int64   `json:"times"`
+	Total float64 `json:"total"`
+	Min   float64 `json:"min"`
+	Max   float64 `json:"max"`
+	Avg   float64 `json:"avg"`
+	Last  float64 `json:"last"`
+}
+
+func newRuntimeStatsMetric(name string) *RuntimeStatsMetric {
+	return &RuntimeStatsMetric{
+		Name: name,
+		Min:  math.MaxFloat64,
+	}
+}
+
+func (metric *RuntimeStatsMetric) record(v float64, n int64) {
+	metric.Times += n
+	metric.Total += v * float64(n)
+	metric.Last = v
+
+	if v < metric.Min {
+		metric.Min = v
+	}
+
+	if v > metric.Max {
+		metric.Max = v
+	}
+}
+
+// reset - Last is kept
+func (metric *RuntimeStatsMetric) reset() {
+	metric.Times = 0
+	metric.Total = 0
+	metric.Min = math.MaxFloat64
+	metric.Max = 0
+}
+
+// clone - Avg is computed, Min is 0 if there is no sample
+func (metric *RuntimeStatsMetric) clone() *RuntimeStatsMetric {
+	nm := *metric
+
+	if nm.Times > 0 {
+		nm.Avg = nm.Total / float64(nm.Times)
+	} else {
+		nm.Min = 0
+	}
+
+	return &nm
+}
+
+// runtimeStatsSample - a sample of runtime/metrics, last is the previous value of a cumulative metric
+type runtimeStatsSample struct {
+	metric       *RuntimeStatsMetric
+	isCumulative bool
+	isSampled    bool
+	last         float64
+	lastCounts   []uint64
+}
+
+// RuntimeStats - samples runtime/metrics on every TickerSample, and outputs min / max / avg of the samples on every TickerOutput.
+// After ServStats.SetRuntimeStats, the metrics are written into the outputs of the ServStats instead.
+type RuntimeStats struct {
+	Type         string                         `json:"type"`
+	Metrics      map[string]*RuntimeStatsMetric `json:"metrics,omitempty"`
+	ChanState    chan int                       `json:"-"`
+	TickerSample *time.Ticker                   `json:"-"`
+	TickerOutput *time.Ticker                   `json:"-"`
+	PathOutput   string                         `json:"-"`
+	StartTs      int64                          `json:"startTs"`
+	EndTs        int64                          `json:"endTs"`
+	prefixFN     string                         `json:"-"`
+	samples      []metrics.Sample               `json:"-"`
+	lstSample    []*runtimeStatsSample          `json:"-"`
+	fds          *RuntimeStatsMetric            `json:"-"`
+	timer        ITime                          `json:"-"`
+	sinks        []StatsSink                    `json:"-"`
+	loop         *statsLoop                     `json:"-"`
+	isAttached   bool                           `json:"-"`
+	lock         sync.Mutex                     `json:"-"`
+}
+
+// NewRuntimeStats - new RuntimeStats, the metrics not supported by the runtime or the system are skipped
+func NewRuntimeStats(sampleTimer time.Duration, outputTimer time.Duration, pathOutput string, prefixFN string) *RuntimeStats {
+	stats := &RuntimeStats{
+		Type:         StatsSnapshotTypeRuntime,
+		Metrics:      make(map[string]*RuntimeStatsMetric),
+		ChanState:    make(chan int),
+		TickerSample: time.NewTicker(sampleTimer),
+		TickerOutput: time.NewTicker(outputTimer),
+		PathOutput:   pathOutput,
+		prefixFN:     prefixFN,
+		timer:        gTime,
+		sinks:        []StatsSink{NewFileStatsSink(pathOutput, 0, 0)},
+	}
+
+	stats.loop = newStatsLoopWithSample("RuntimeStats", stats.TickerSample, stats.Sample)
+
+	supported := make(map[string]metrics.Description)
+	for _, desc := range metrics.All() {
+		supported[desc.Name] = desc
+	}
+
+	for _, desc := range runtimeStatsDescs {
+		for _, name := range desc.samples {
+			mdesc, isok := supported[name]
+			if !isok {
+				continue
+			}
+
+			stats.Metrics[desc.name] = newRuntimeStatsMetric(desc.name)
+			stats.samples = append(stats.samples, metrics.Sample{Name: name})
+			stats.lstSample = append(stats.lstSample, &runtimeStatsSample{
+				metric:       stats.Metrics[desc.name],
+				isCumulative: mdesc.Cumulative,
+			})
+
+			break
+		}
+	}
+
+	_, err := countFDs()
+	if err == nil {
+		stats.fds = newRuntimeStatsMetric(RuntimeStatsFDs)
+		stats.Metrics[RuntimeStatsFDs] = stats.fds
+	}
+
+	stats.StartTs = stats.timer.Now().Unix()
+
+	return stats
+}
+
+// countFDs - number of the entries in /proc/self/fd
+func countFDs() (int, error) {
+	entries, err := os.ReadDir("/proc/self/fd")
+	if err != nil {
+		return 0, err
+	}
+
+	return len(entries), nil
+}
+
+// SetTimer - the clock of the output timestamps
+func (stats *RuntimeStats) SetTimer(timer ITime) {
+	stats.lock.Lock()
+	stats.timer = timer
+	stats.StartTs = timer.Now().Unix()
+	stats.lock.Unlock()
+}
+
+// AddSink - add an output target, a FileStatsSink of pathOutput is added by NewRuntimeStats
+func (stats *RuntimeStats) AddSink(sink StatsSink) {
+	stats.lock.Lock()
+	stats.sinks = append(stats.sinks, sink)
+	stats.lock.Unlock()
+}
+
+// SetSinks - replace all output targets
+func (stats *RuntimeStats) SetSinks(sinks ...StatsSink) {
+	stats.lock.Lock()
+	stats.sinks = sinks
+	stats.lock.Unlock()
+}
+
+// Start - start the goroutine, it samples on every TickerSample and outputs on every TickerOutput until ctx is done or Stop is called.
+// It returns ErrAlreadyStarted if it is called twice.
+func (stats *RuntimeStats) Start(ctx context.Context) error {
+	return stats.loop.start(ctx, stats.TickerOutput, stats.ChanState, stats.Output)
+}
+
+// Stop - stop the goroutine and flush a final Output, it can be called more than once or without Start
+func (stats *RuntimeStats) Stop() {
+	stats.loop.stop(stats.TickerOutput, stats.Output)
+}
+
+// Wait - wait for the goroutine to exit, like after ctx is done
+func (stats *RuntimeStats) Wait() {
+	stats.loop.wait()
+}
+
+// Sample - read the metrics once, the first sample of a cumulative metric is only its baseline
+func (stats *RuntimeStats) Sample() {
+	fds, errFDs := 0, error(nil)
+	if stats.fds != nil {
+		fds, errFDs = countFDs()
+	}
+
+	stats.lock.Lock()
+	defer stats.lock.Unlock()
+
+	metrics.Read(stats.samples)
+
+	for i, sample := range stats.samples {
+		stats.lstSample[i].record(sample.Value)
+	}
+
+	if stats.fds != nil && errFDs == nil {
+		stats.fds.record(float64(fds), 1)
+	}
+}
+
+func (sample *runtimeStatsSample) record(value metrics.Value) {
+	isSampled := sample.isSampled
+	sample.isSampled = true
+
+	switch value.Kind() {
+	case metrics.KindUint64:
+		sample.recordValue(float64(value.Uint64()), isSampled)
+	case metrics.KindFloat64:
+		sample.recordValue(value.Float64(), isSampled)
+	case metrics.KindFloat64Histogram:
+		h := value.Float64Histogram()
+
+		if isSampled && len(sample.lastCounts) == len(h.Counts) {
+			for i, c := range h.Counts {
+				if c > sample.lastCounts[i] {
+					sample.metric.record(runtimeStatsBucketValue(h.Buckets[i], h.Buckets[i+1]), int64(c-sample.lastCounts[i]))
+				}
+			}
+		}
+
+		sample.lastCounts = append(sample.lastCounts[:0], h.Counts...)
+	}
+}
+
+// recordValue - the delta is recorded if the metric is cumulative
+func (sample *runtimeStatsSample) recordValue(v float64, isSampled bool) {
+	if !sample.isCumulative {
+		sample.metric.record(v, 1)
+
+		return
+	}
+
+	if isSampled {
+		sample.metric.record(v-sample.last, 1)
+	}
+
+	sample.last = v
+}
+
+// runtimeStatsBucketValue - the middle of [lower, upper), the bounds of the first and the last buckets can be infinite
+func runtimeStatsBucketValue(lower float64, upper float64) float64 {
+	if math.IsInf(lower, -1) {
+		return upper
+	}
+
+	if math.IsInf(upper, 1) {
+		return lower
+	}
+
+	return (lower + upper) / 2
+}
+
+// Output - output the metrics of this interval, and reset them.
+// It does nothing if stats is attached to a ServStats, the ServStats outputs them.
+func (stats *RuntimeStats) Output() {
+	json := jsoniter.ConfigCompatibleWithStandardLibrary
+
+	stats.lock.Lock()
+	if stats.isAttached {
+		stats.lock.Unlock()
+
+		return
+	}
+
+	now := stats.timer.Now()
+	output := stats.takeOutput(now)
+
+	sinks := stats.sinks
+	stats.lock.Unlock()
+
+	b, err := json.Marshal(output)
+	if err != nil {
+		Warn("RuntimeStats.output:Marshal",
+			Err(err))
+
+		return
+	}
+
+	writeStatsDump("RuntimeStats.output:Write", sinks, &StatsDump{
+		Prefix: stats.prefixFN,
+		Time:   now,
+		Data:   b,
+		Header: runtimeStatsCSVHeader,
+		Rows:   output.csvRows(),
+	})
+}
+
+// attach - the metrics are output by the ServStats, see ServStats.SetRuntimeStats
+func (stats *RuntimeStats) attach() {
+	stats.lock.Lock()
+	stats.isAttached = true
+	stats.lock.Unlock()
+}
+
+// outputAttached - the metrics of this interval for the output of the ServStats, and reset them
+func (stats *RuntimeStats) outputAttached() *RuntimeStats {
+	stats.lock.Lock()
+	defer stats.lock.Unlock()
+
+	return stats.takeOutput(stats.timer.Now())
+}
+
+// takeOutput - a copy of the metrics of this interval, and reset them, stats.lock must be locked
+func (stats *RuntimeStats) takeOutput(now time.Time) *RuntimeStats {
+	output := &RuntimeStats{
+		Type:    stats.Type,
+		Metrics: stats.cloneMetrics(),
+		StartTs: stats.StartTs,
+		EndTs:   now.Unix(),
+	}
+
+	for _, m := range stats.Metrics {
+		m.reset()
+	}
+
+	stats.StartTs = output.EndTs
+	stats.EndTs = output.EndTs
+
+	return output
+}
+
+// cloneMetrics - stats.lock must be locked
+func (stats *RuntimeStats) cloneMetrics() map[string]*RuntimeStatsMetric {
+	mapMetrics := make(map[string]*RuntimeStatsMetric, len(stats.Metrics))
+	for k, v := range stats.Metrics {
+		mapMetrics[k] = v.clone()
+	}
+
+	return mapMetrics
+}
+
+var runtimeStatsCSVHeader = []string{"name", "times", "min", "max", "avg", "last"}
+
+// csvRows - one row per metric, sorted by name
+func (stats *RuntimeStats) csvRows() [][]string {
+	names := make([]string, 0, len(stats.Metrics))
+	for k := range stats.Metrics {
+		names = append(names, k)
+	}
+
+	sort.Strings(names)
+
+	rows := [][]string{}
+	for _, name := range names {
+		m := stats.Metrics[name]
+
+		rows = append(rows, []string{
+			m.Name,
+			strconv.FormatInt(m.Times, 10),
+			formatStatsFloat(m.Min),
+			formatStatsFloat(m.Max),
+			formatStatsFloat(m.Avg),
+			formatStatsFloat(m.Last),
+		})
+	}
+
+	return rows
+}
@@ -0,0 +1,160 @@
+package goutils
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"runtime"
+	"testing"
+	"time"
+
+	gomock "github.com/golang/mock/gomock"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_RuntimeStats(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := NewMockITime(ctrl)
+	m.EXPECT().Now().Return(time.Unix(1597647600, 0)).Times(1)
+	m.EXPECT().Now().Return(time.Unix(1597647660, 0)).Times(1)
+
+	dir := t.TempDir()
+
+	stats := NewRuntimeStats(time.Hour, time.Hour, dir, "runtime")
+	stats.SetTimer(m)
+
+	stats.Sample()
+
+	done := make(chan int)
+	for i := 0; i < 10; i++ {
+		go func() {
+			<-done
+		}()
+	}
+
+	runtime.GC()
+	stats.Sample()
+
+	close(done)
+
+	goroutines := stats.Metrics[RuntimeStatsGoroutines]
+	assert.Equal(t, goroutines.Times, int64(2))
+	assert.True(t, goroutines.Max-goroutines.Min >= 10)
+	assert.Equal(t, goroutines.Last, goroutines.Max)
+
+	// the first sample of a cumulative metric is the baseline
+	assert.Equal(t, stats.Metrics[RuntimeStatsGCCycles].Times, int64(1))
+	assert.True(t, stats.Metrics[RuntimeStatsGCCycles].Total >= 1)
+	assert.True(t, stats.Metrics[RuntimeStatsGCPauseSeconds].Times >= 1)
+	assert.True(t, stats.Metrics[RuntimeStatsHeapObjectsBytes].Min > 0)
+
+	if runtime.GOOS == "linux" {
+		assert.True(t, stats.Metrics[RuntimeStatsFDs].Min > 0)
+	}
+
+	handler := NewPromHandler("game", nil)
+	handler.AddRuntimeStats(stats)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assert.Contains(t, rec.Body.String(), "# TYPE game_runtime_goroutines gauge")
+	assert.Contains(t, rec.Body.String(), `game_runtime_gc_cycles_min{stats="runtime"} `)
+
+	stats.Output()
+
+	data, err := os.ReadFile(path.Join(dir, "runtime.1597647660.json"))
+	assert.NoError(t, err)
+
+	json := jsoniter.ConfigCompatibleWithStandardLibrary
+
+	output := &RuntimeStats{}
+	assert.NoError(t, json.Unmarshal(data, output))
+	assert.Equal(t, output.StartTs, int64(1597647600))
+	assert.Equal(t, output.EndTs, int64(1597647660))
+	assert.Equal(t, output.Metrics[RuntimeStatsGoroutines].Times, int64(2))
+	assert.Equal(t, output.Metrics[RuntimeStatsGoroutines].Avg,
+		(output.Metrics[RuntimeStatsGoroutines].Min+output.Metrics[RuntimeStatsGoroutines].Max)/2)
+
+	// reset after every Output
+	assert.Equal(t, stats.Metrics[RuntimeStatsGoroutines].Times, int64(0))
+	assert.Equal(t, stats.StartTs, int64(1597647660))
+
+	t.Logf("Test_RuntimeStats OK")
+}
+
+func Test_RuntimeStatsLifecycle(t *testing.T) {
+	dir := t.TempDir()
+
+	stats := NewRuntimeStats(time.Millisecond, time.Hour, dir, "runtime")
+	stats.SetSinks()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	assert.NoError(t, stats.Start(ctx))
+	assert.ErrorIs(t, stats.Start(ctx), ErrAlreadyStarted)
+
+	time.Sleep(20 * time.Millisecond)
+
+	cancel()
+	stats.Wait()
+	stats.Stop()
+
+	assert.True(t, stats.Metrics[RuntimeStatsGoroutines].Last > 0)
+
+	t.Logf("Test_RuntimeStatsLifecycle OK")
+}
+
+func Test_ServStatsRuntimeStats(t *testing.T) {
+	dir := t.TempDir()
+
+	servstats := NewServStats(10, 0, time.Hour, dir, 16, "servstats")
+	servstats.RegMsg("spin")
+	servstats.EndMsg("spin", servstats.StartMsg("spin"))
+
+	stats := NewRuntimeStats(time.Hour, time.Hour, dir, "runtime")
+	servstats.SetRuntimeStats(stats)
+
+	stats.Sample()
+
+	// it is output by the ServStats
+	stats.Output()
+
+	lst, err := ListStatsFiles(dir, "runtime")
+	assert.NoError(t, err)
+	assert.Equal(t, len(lst), 0)
+	assert.Equal(t, stats.Metrics[RuntimeStatsGoroutines].Times, int64(1))
+
+	servstats.Output()
+
+	lst, err = ListStatsFiles(dir, "servstats")
+	assert.NoError(t, err)
+	assert.Equal(t, len(lst), 1)
+
+	data, err := os.ReadFile(lst[0])
+	assert.NoError(t, err)
+
+	json := jsoniter.ConfigCompatibleWithStandardLibrary
+
+	output := &ServStats{}
+	assert.NoError(t, json.Unmarshal(data, output))
+	assert.Equal(t, output.MapMsgs["spin"].TotalTimes, 1)
+	assert.NotNil(t, output.Runtime)
+	assert.Equal(t, output.Runtime.Metrics[RuntimeStatsGoroutines].Times, int64(1))
+	assert.True(t, output.Runtime.Metrics[RuntimeStatsGoroutines].Last > 0)
+
+	// reset after every Output of the ServStats
+	assert.Equal(t, stats.Metrics[RuntimeStatsGoroutines].Times, int64(0))
+
+	// the snapshot loader skips the runtime metrics
+	snapshot, err := LoadStatsSnapshots(dir, "servstats", "", false)
+	assert.NoError(t, err)
+	assert.Equal(t, len(snapshot.Items), 1)
+	assert.Equal(t, snapshot.Items["spin"].Times, int64(1))
+
+	t.Logf("Test_ServStatsRuntimeStats OK")
+}
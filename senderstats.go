@@ -1,11 +1,9 @@
 package goutils
 
 import (
-	"fmt"
-	"math"
-	"os"
-	"path"
+	"context"
 	"sort"
+	"strconv"
 	"sync"
 	"time"
 
@@ -13,104 +11,165 @@ import (
 )
 
 type SenderStatsNode struct {
-	Name       string `json:"name,omitempty"`
-	TotalBytes int64  `json:"totalBytes,omitempty"`
-	TotalTimes int    `json:"totalTimes,omitempty"`
-	MaxBytes   int    `json:"maxBytes,omitempty"`
-	MinBytes   int    `json:"minBytes,omitempty"`
-	Nodes      []int  `json:"nodes,omitempty"`
-}
-
-func newSenderStatsNode(name string) *SenderStatsNode {
-	msg := &SenderStatsNode{
-		Name:     name,
-		MaxBytes: 0,
-		MinBytes: math.MaxInt32,
-	}
+	Name       string         `json:"name,omitempty"`
+	TotalBytes int64          `json:"totalBytes,omitempty"`
+	TotalTimes int            `json:"totalTimes,omitempty"`
+	MaxBytes   int            `json:"maxBytes,omitempty"`
+	MinBytes   int            `json:"minBytes,omitempty"`
+	Nodes      []int          `json:"nodes,omitempty"`
+	Histogram  *Histogram     `json:"histogram,omitempty"`
+	Windows    []*WindowStats `json:"windows,omitempty"`
+}
 
-	return msg
+type SenderStats struct {
+	Type              string                      `json:"type"`
+	Instance          string                      `json:"instance,omitempty"`
+	MapNodes          map[string]*SenderStatsNode `json:"mapMsgs,omitempty"`
+	MaxNodes          int                         `json:"-"`
+	ChanState         chan int                    `json:"-"`
+	TickerOutput      *time.Ticker                `json:"-"`
+	PathOutput        string                      `json:"-"`
+	HistogramAccuracy float64                     `json:"-"`
+	WindowSlot        time.Duration               `json:"-"`
+	Windows           []time.Duration             `json:"-"`
+	ResetOnOutput     bool                        `json:"-"`
+	StartTs           int64                       `json:"startTs"`
+	EndTs             int64                       `json:"endTs"`
+	prefixFN          string                      `json:"-"`
+	timer             ITime                       `json:"-"`
+	sinks             []StatsSink                 `json:"-"`
+	counters          sync.Map                    `json:"-"`
+	loop              *statsLoop                  `json:"-"`
+	lock              sync.Mutex                  `json:"-"`
 }
 
-func (node *SenderStatsNode) push(bytes int, maxNodes int) {
-	if bytes > node.MaxBytes {
-		node.MaxBytes = bytes
+func NewSenderStats(maxNodes int, chanSize int, outputTimer time.Duration, pathOutput string, prefixFN string) *SenderStats {
+	stats := &SenderStats{
+		Type:              StatsSnapshotTypeSender,
+		Instance:          defaultStatsInstance(),
+		MapNodes:          make(map[string]*SenderStatsNode),
+		MaxNodes:          maxNodes,
+		ChanState:         make(chan int, chanSize),
+		TickerOutput:      time.NewTicker(outputTimer),
+		PathOutput:        pathOutput,
+		HistogramAccuracy: DefaultHistogramAccuracy,
+		prefixFN:          prefixFN,
+		timer:             gTime,
+		sinks:             []StatsSink{NewFileStatsSink(pathOutput, 0, 0)},
+		loop:              newStatsLoop("SenderStats"),
 	}
 
-	if bytes < node.MinBytes {
-		node.MinBytes = bytes
-	}
+	stats.StartTs = stats.timer.Now().Unix()
 
-	node.TotalTimes++
-	node.TotalBytes += int64(bytes)
-	node.Nodes = append(node.Nodes, bytes)
+	return stats
+}
 
-	if len(node.Nodes) > maxNodes*2 {
-		sort.Slice(node.Nodes, func(i, j int) bool {
-			return node.Nodes[i] > node.Nodes[j]
-		})
+// SetTimer - the clock of the windows and the output timestamps
+func (stats *SenderStats) SetTimer(timer ITime) {
+	stats.lock.Lock()
+	stats.timer = timer
+	stats.StartTs = timer.Now().Unix()
+	stats.lock.Unlock()
+}
 
-		node.Nodes = node.Nodes[:maxNodes]
-	}
+// SetInstance - the instance name in the dumps, default is the hostname, see LoadStatsSnapshots
+func (stats *SenderStats) SetInstance(instance string) {
+	stats.lock.Lock()
+	stats.Instance = instance
+	stats.lock.Unlock()
 }
 
-func (node *SenderStatsNode) sort() {
-	sort.Slice(node.Nodes, func(i, j int) bool {
-		return node.Nodes[i] > node.Nodes[j]
-	})
+// AddSink - add an output target, a FileStatsSink of pathOutput is added by NewSenderStats
+func (stats *SenderStats) AddSink(sink StatsSink) {
+	stats.lock.Lock()
+	stats.sinks = append(stats.sinks, sink)
+	stats.lock.Unlock()
 }
 
-type SenderStats struct {
-	MapNodes     map[string]*SenderStatsNode `json:"mapMsgs,omitempty"`
-	MaxNodes     int                         `json:"-"`
-	ChanState    chan int                    `json:"-"`
-	TickerOutput *time.Ticker                `json:"-"`
-	PathOutput   string                      `json:"-"`
-	prefixFN     string                      `json:"-"`
-	lock         sync.Mutex                  `json:"-"`
+// SetSinks - replace all output targets
+func (stats *SenderStats) SetSinks(sinks ...StatsSink) {
+	stats.lock.Lock()
+	stats.sinks = sinks
+	stats.lock.Unlock()
 }
 
-func NewSenderStats(maxNodes int, chanSize int, outputTimer time.Duration, pathOutput string, prefixFN string) *SenderStats {
-	stats := &SenderStats{
-		MapNodes:     make(map[string]*SenderStatsNode),
-		MaxNodes:     maxNodes,
-		ChanState:    make(chan int),
-		TickerOutput: time.NewTicker(outputTimer),
-		PathOutput:   pathOutput,
-		prefixFN:     prefixFN,
-	}
+// SetWindows - keep rolling windows like 1m / 5m / 15m with ring-buffered slots, only for names pushed later
+func (stats *SenderStats) SetWindows(slot time.Duration, windows ...time.Duration) {
+	stats.lock.Lock()
+	stats.WindowSlot = slot
+	stats.Windows = windows
+	stats.lock.Unlock()
+}
 
-	return stats
+// SetResetOnOutput - if isReset is true, the counters are reset after every Output
+func (stats *SenderStats) SetResetOnOutput(isReset bool) {
+	stats.lock.Lock()
+	stats.ResetOnOutput = isReset
+	stats.lock.Unlock()
 }
 
-func (stats *SenderStats) Start() {
-	go stats.mainLoop()
+// SetHistogramAccuracy - relative accuracy of the size histogram, like 0.01, only for names pushed later,
+// it is raised to about 0.0053 if it is smaller, see maxAtomicHistogramBuckets
+func (stats *SenderStats) SetHistogramAccuracy(accuracy float64) {
+	stats.lock.Lock()
+	stats.HistogramAccuracy = accuracy
+	stats.lock.Unlock()
 }
 
+// Start - start the output goroutine, it outputs on every tick until ctx is done or Stop is called.
+// It returns ErrAlreadyStarted if it is called twice.
+func (stats *SenderStats) Start(ctx context.Context) error {
+	return stats.loop.start(ctx, stats.TickerOutput, stats.ChanState, stats.Output)
+}
+
+// Stop - stop the output goroutine and flush a final Output, it can be called more than once or without Start
 func (stats *SenderStats) Stop() {
-	stats.TickerOutput.Stop()
-	stats.ChanState <- 0
+	stats.loop.stop(stats.TickerOutput, stats.Output)
+}
+
+// Wait - wait for the output goroutine to exit, like after ctx is done
+func (stats *SenderStats) Wait() {
+	stats.loop.wait()
 }
 
+// Push - record a message, it is lock-free unless name is new
 func (stats *SenderStats) Push(name string, bytes int) {
-	stats.lock.Lock()
-	defer stats.lock.Unlock()
-
-	node, isok := stats.MapNodes[name]
-	if isok {
-		node.push(bytes, stats.MaxNodes)
-	} else {
-		stats.MapNodes[name] = newSenderStatsNode(name)
-		stats.MapNodes[name].push(bytes, stats.MaxNodes)
+	v, isok := stats.counters.Load(name)
+	if !isok {
+		v = stats.newCounter(name)
 	}
+
+	v.(*senderStatsCounter).push(bytes)
+}
+
+func (stats *SenderStats) newCounter(name string) *senderStatsCounter {
+	stats.lock.Lock()
+	counter := newSenderStatsCounter(name, stats.MaxNodes, stats.HistogramAccuracy, stats.timer,
+		newRollingStatsWithWindows(stats.WindowSlot, stats.Windows))
+	stats.lock.Unlock()
+
+	v, _ := stats.counters.LoadOrStore(name, counter)
+
+	return v.(*senderStatsCounter)
+}
+
+// collect - rebuild MapNodes from the counters, stats.lock must be locked
+func (stats *SenderStats) collect(now time.Time, isReset bool) {
+	stats.counters.Range(func(key, value interface{}) bool {
+		stats.MapNodes[key.(string)] = value.(*senderStatsCounter).node(now, stats.Windows, isReset)
+
+		return true
+	})
 }
 
 func (stats *SenderStats) Output() {
 	json := jsoniter.ConfigCompatibleWithStandardLibrary
 
 	stats.lock.Lock()
-	for _, v := range stats.MapNodes {
-		v.sort()
-	}
+	now := stats.timer.Now()
+	stats.EndTs = now.Unix()
+
+	stats.collect(now, stats.ResetOnOutput)
 
 	b, err := json.Marshal(stats)
 	if err != nil {
@@ -121,26 +180,59 @@ func (stats *SenderStats) Output() {
 
 		return
 	}
-	stats.lock.Unlock()
 
-	err = os.WriteFile(path.Join(stats.PathOutput, fmt.Sprintf("%v.%v.json", stats.prefixFN, time.Now().Unix())), b, 0644)
-	if err != nil {
-		Warn("SenderStats.output:WriteFile",
-			Err(err))
+	dump := &StatsDump{
+		Prefix: stats.prefixFN,
+		Time:   now,
+		Data:   b,
+		Header: senderStatsCSVHeader,
+		Rows:   stats.csvRows(),
+	}
 
-		return
+	if stats.ResetOnOutput {
+		stats.StartTs = stats.EndTs
 	}
+
+	sinks := stats.sinks
+	stats.lock.Unlock()
+
+	writeStatsDump("SenderStats.output:Write", sinks, dump)
 }
 
-func (stats *SenderStats) mainLoop() {
-	for {
-		select {
-		case <-stats.ChanState:
-			Info("SenderStats:mainLoop:ChanState")
+var senderStatsCSVHeader = []string{"name", "totalTimes", "totalBytes", "avgBytes", "minBytes", "maxBytes", "p50", "p90", "p99"}
+
+// csvRows - one row per name, sorted by name, stats.lock must be locked
+func (stats *SenderStats) csvRows() [][]string {
+	names := make([]string, 0, len(stats.MapNodes))
+	for k := range stats.MapNodes {
+		names = append(names, k)
+	}
+
+	sort.Strings(names)
 
-			return
-		case <-stats.TickerOutput.C:
-			stats.Output()
+	rows := [][]string{}
+	for _, name := range names {
+		node := stats.MapNodes[name]
+
+		avgBytes := 0.0
+		minBytes := 0
+		if node.TotalTimes > 0 {
+			avgBytes = float64(node.TotalBytes) / float64(node.TotalTimes)
+			minBytes = node.MinBytes
 		}
+
+		rows = append(rows, []string{
+			node.Name,
+			strconv.Itoa(node.TotalTimes),
+			strconv.FormatInt(node.TotalBytes, 10),
+			formatStatsFloat(avgBytes),
+			strconv.Itoa(minBytes),
+			strconv.Itoa(node.MaxBytes),
+			formatStatsFloat(node.Histogram.Percentile(50)),
+			formatStatsFloat(node.Histogram.Percentile(90)),
+			formatStatsFloat(node.Histogram.Percentile(99)),
+		})
 	}
+
+	return rows
 }
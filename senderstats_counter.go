@@ -0,0 +1,269 @@
+package goutils
+
+import (
+	"container/heap"
+	"math"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// atomicHistogram - the buckets of Histogram in a dense array of atomic counters, for int values >= 0
+type atomicHistogram struct {
+	relativeAccuracy float64
+	lnGamma          float64
+	zeroCount        atomic.Int64
+	buckets          []atomic.Int64
+}
+
+// maxAtomicHistogramBuckets - the max buckets of atomicHistogram, 32KB per name.
+// A smaller relative accuracy needs more buckets, so it is raised to about 0.53%.
+const maxAtomicHistogramBuckets = 4096
+
+func newAtomicHistogram(relativeAccuracy float64) *atomicHistogram {
+	h := NewHistogram(relativeAccuracy)
+	if h.index(math.MaxInt64)+1 > maxAtomicHistogramBuckets {
+		// the smallest gamma with index(math.MaxInt64) < maxAtomicHistogramBuckets, with a bucket of margin for rounding
+		gamma := math.Exp(math.Log(math.MaxInt64) / (maxAtomicHistogramBuckets - 2))
+		h = NewHistogram((gamma - 1) / (gamma + 1))
+	}
+
+	return &atomicHistogram{
+		relativeAccuracy: h.RelativeAccuracy,
+		lnGamma:          h.lnGamma,
+		buckets:          make([]atomic.Int64, h.index(math.MaxInt64)+1),
+	}
+}
+
+func (h *atomicHistogram) record(v int) {
+	if v <= 0 {
+		h.zeroCount.Add(1)
+
+		return
+	}
+
+	h.buckets[int(math.Ceil(math.Log(float64(v))/h.lnGamma))].Add(1)
+}
+
+// histogram - the buckets as a Histogram, Sum / Min / Max are not set, the counters are cleared if isReset is true
+func (h *atomicHistogram) histogram(isReset bool) *Histogram {
+	hist := NewHistogram(h.relativeAccuracy)
+
+	hist.ZeroCount = loadOrSwapInt64(&h.zeroCount, 0, isReset)
+	hist.Count = hist.ZeroCount
+
+	for i := range h.buckets {
+		n := loadOrSwapInt64(&h.buckets[i], 0, isReset)
+		if n > 0 {
+			hist.Buckets[i] = n
+			hist.Count += n
+		}
+	}
+
+	return hist
+}
+
+// intMinHeap - container/heap of ints, the smallest is the first
+type intMinHeap []int
+
+func (h intMinHeap) Len() int           { return len(h) }
+func (h intMinHeap) Less(i, j int) bool { return h[i] < h[j] }
+func (h intMinHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+
+func (h *intMinHeap) Push(x interface{}) {
+	*h = append(*h, x.(int))
+}
+
+func (h *intMinHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	*h = old[:n-1]
+
+	return x
+}
+
+// topKInts - the k largest values.
+// When it is full, values <= the smallest one are dropped with an atomic load, without the lock.
+type topKInts struct {
+	k         int
+	threshold atomic.Int64
+	lock      sync.Mutex
+	values    intMinHeap
+}
+
+func newTopKInts(k int) *topKInts {
+	top := &topKInts{
+		k: k,
+	}
+
+	top.threshold.Store(math.MinInt64)
+
+	return top
+}
+
+func (top *topKInts) push(v int) {
+	if top.k <= 0 || int64(v) <= top.threshold.Load() {
+		return
+	}
+
+	top.lock.Lock()
+	defer top.lock.Unlock()
+
+	if len(top.values) < top.k {
+		heap.Push(&top.values, v)
+	} else if v > top.values[0] {
+		top.values[0] = v
+		heap.Fix(&top.values, 0)
+	} else {
+		return
+	}
+
+	if len(top.values) == top.k {
+		top.threshold.Store(int64(top.values[0]))
+	}
+}
+
+// sorted - the values in descending order, they are cleared if isReset is true
+func (top *topKInts) sorted(isReset bool) []int {
+	top.lock.Lock()
+	lst := append([]int{}, top.values...)
+	if isReset {
+		top.values = top.values[:0]
+		top.threshold.Store(math.MinInt64)
+	}
+	top.lock.Unlock()
+
+	sort.Slice(lst, func(i, j int) bool {
+		return lst[i] > lst[j]
+	})
+
+	return lst
+}
+
+// senderStatsCounter - the counters of a name, they are updated with atomics
+type senderStatsCounter struct {
+	name        string
+	totalBytes  atomic.Int64
+	totalTimes  atomic.Int64
+	maxBytes    atomic.Int64
+	minBytes    atomic.Int64
+	histogram   *atomicHistogram
+	top         *topKInts
+	timer       ITime
+	rolling     *RollingStats
+	lockRolling sync.Mutex
+
+	// the counters cleared by ResetOnOutput, so the counters of PromHandler never decrease, guarded by SenderStats.lock
+	cumulativeTimes     int64
+	cumulativeBytes     int64
+	cumulativeHistogram *Histogram
+}
+
+func newSenderStatsCounter(name string, maxNodes int, histogramAccuracy float64, timer ITime, rolling *RollingStats) *senderStatsCounter {
+	counter := &senderStatsCounter{
+		name:      name,
+		histogram: newAtomicHistogram(histogramAccuracy),
+		top:       newTopKInts(maxNodes),
+		timer:     timer,
+		rolling:   rolling,
+	}
+
+	counter.minBytes.Store(math.MaxInt64)
+
+	return counter
+}
+
+// push - only the rolling windows take a lock, if they are enabled
+func (counter *senderStatsCounter) push(bytes int) {
+	counter.totalTimes.Add(1)
+	counter.totalBytes.Add(int64(bytes))
+
+	for {
+		cur := counter.maxBytes.Load()
+		if int64(bytes) <= cur || counter.maxBytes.CompareAndSwap(cur, int64(bytes)) {
+			break
+		}
+	}
+
+	for {
+		cur := counter.minBytes.Load()
+		if int64(bytes) >= cur || counter.minBytes.CompareAndSwap(cur, int64(bytes)) {
+			break
+		}
+	}
+
+	counter.histogram.record(bytes)
+	counter.top.push(bytes)
+
+	if counter.rolling != nil {
+		now := counter.timer.Now()
+
+		counter.lockRolling.Lock()
+		counter.rolling.Record(now, float64(bytes))
+		counter.lockRolling.Unlock()
+	}
+}
+
+// node - the counters as a SenderStatsNode, they are cleared if isReset is true.
+// Every counter of a push concurrent with a reset is counted once, in the old or the new node.
+func (counter *senderStatsCounter) node(now time.Time, windows []time.Duration, isReset bool) *SenderStatsNode {
+	node := &SenderStatsNode{
+		Name:       counter.name,
+		TotalTimes: int(loadOrSwapInt64(&counter.totalTimes, 0, isReset)),
+		TotalBytes: loadOrSwapInt64(&counter.totalBytes, 0, isReset),
+		MaxBytes:   int(loadOrSwapInt64(&counter.maxBytes, 0, isReset)),
+		MinBytes:   math.MaxInt32,
+		Nodes:      counter.top.sorted(isReset),
+		Histogram:  counter.histogram.histogram(isReset),
+	}
+
+	minBytes := loadOrSwapInt64(&counter.minBytes, math.MaxInt64, isReset)
+	if node.TotalTimes > 0 {
+		node.MinBytes = int(minBytes)
+
+		node.Histogram.Sum = float64(node.TotalBytes)
+		node.Histogram.Min = float64(node.MinBytes)
+		node.Histogram.Max = float64(node.MaxBytes)
+	}
+
+	if counter.rolling != nil {
+		counter.lockRolling.Lock()
+		node.Windows = counter.rolling.queryWindows(now, windows)
+		counter.lockRolling.Unlock()
+	}
+
+	if isReset {
+		if counter.cumulativeHistogram == nil {
+			counter.cumulativeHistogram = NewHistogram(counter.histogram.relativeAccuracy)
+		}
+
+		counter.cumulativeTimes += int64(node.TotalTimes)
+		counter.cumulativeBytes += node.TotalBytes
+		counter.cumulativeHistogram.Merge(node.Histogram)
+	}
+
+	return node
+}
+
+// cumulativeCounters - TotalTimes, TotalBytes and Histogram of node since the start, with the counters cleared by ResetOnOutput,
+// node is the last node without reset, SenderStats.lock must be locked
+func (counter *senderStatsCounter) cumulativeCounters(node *SenderStatsNode) (int64, int64, *Histogram) {
+	if counter.cumulativeHistogram == nil {
+		return int64(node.TotalTimes), node.TotalBytes, node.Histogram
+	}
+
+	histogram := counter.cumulativeHistogram.Clone()
+	histogram.Merge(node.Histogram)
+
+	return counter.cumulativeTimes + int64(node.TotalTimes), counter.cumulativeBytes + node.TotalBytes, histogram
+}
+
+func loadOrSwapInt64(v *atomic.Int64, newValue int64, isSwap bool) int64 {
+	if isSwap {
+		return v.Swap(newValue)
+	}
+
+	return v.Load()
+}
@@ -0,0 +1,205 @@
+package goutils
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_SenderStatsPush(t *testing.T) {
+	stats := NewSenderStats(5, 0, time.Hour, t.TempDir(), "senderstats")
+
+	values := rand.Perm(100)
+	for _, v := range values {
+		stats.Push("msg", v+1)
+	}
+
+	snapshot := stats.Snapshot()
+	assert.Equal(t, snapshot.Items["msg"].Times, int64(100))
+	assert.Equal(t, snapshot.Items["msg"].Total, 5050.0)
+	assert.Equal(t, snapshot.Items["msg"].Min, 1.0)
+	assert.Equal(t, snapshot.Items["msg"].Max, 100.0)
+	assert.Equal(t, snapshot.Items["msg"].Histogram.Count, int64(100))
+	assert.InDelta(t, snapshot.Items["msg"].Histogram.Percentile(50), 50, 1)
+	assert.InDelta(t, snapshot.Items["msg"].Histogram.Percentile(99), 99, 1)
+
+	assert.Equal(t, stats.MapNodes["msg"].Nodes, []int{100, 99, 98, 97, 96})
+
+	stats.Push("msg", 0)
+
+	stats.lock.Lock()
+	stats.collect(time.Now(), true)
+	assert.Equal(t, stats.MapNodes["msg"].MinBytes, 0)
+	assert.Equal(t, stats.MapNodes["msg"].Histogram.ZeroCount, int64(1))
+	stats.collect(time.Now(), false)
+	stats.lock.Unlock()
+
+	assert.Equal(t, stats.MapNodes["msg"].TotalTimes, 0)
+	assert.Equal(t, len(stats.MapNodes["msg"].Nodes), 0)
+
+	t.Logf("Test_SenderStatsPush OK")
+}
+
+func Test_SenderStatsPushRace(t *testing.T) {
+	stats := NewSenderStats(10, 0, time.Hour, t.TempDir(), "senderstats")
+	stats.SetWindows(time.Second, time.Minute)
+
+	const goroutines = 8
+	const pushes = 1000
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			for j := 0; j < pushes; j++ {
+				stats.Push(fmt.Sprintf("msg%v", j%4), i*pushes+j)
+			}
+		}(i)
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		for j := 0; j < 10; j++ {
+			stats.Snapshot()
+		}
+	}()
+
+	wg.Wait()
+
+	snapshot := stats.Snapshot()
+
+	times := int64(0)
+	top := []int{}
+	for _, name := range []string{"msg0", "msg1", "msg2", "msg3"} {
+		times += snapshot.Items[name].Times
+		top = append(top, stats.MapNodes[name].Nodes...)
+	}
+
+	assert.Equal(t, times, int64(goroutines*pushes))
+
+	sort.Sort(sort.Reverse(sort.IntSlice(top)))
+	assert.Equal(t, top[0], goroutines*pushes-1)
+	assert.Equal(t, stats.MapNodes["msg3"].Nodes[9], goroutines*pushes-37)
+
+	t.Logf("Test_SenderStatsPushRace OK")
+}
+
+func Test_AtomicHistogramBuckets(t *testing.T) {
+	h := newAtomicHistogram(DefaultHistogramAccuracy)
+	assert.Equal(t, h.relativeAccuracy, DefaultHistogramAccuracy)
+
+	// the accuracy is raised to keep the buckets small
+	h = newAtomicHistogram(0.000001)
+	assert.True(t, len(h.buckets) <= maxAtomicHistogramBuckets)
+	assert.True(t, h.relativeAccuracy > 0.005 && h.relativeAccuracy < 0.006)
+
+	h.record(math.MaxInt64)
+	h.record(1000)
+
+	hist := h.histogram(false)
+	assert.Equal(t, hist.Count, int64(2))
+	assert.Equal(t, len(hist.Buckets), 2)
+
+	t.Logf("Test_AtomicHistogramBuckets OK")
+}
+
+// mutexSenderStats - the Push of SenderStats before the atomic counters, one lock for all names, for Benchmark_SenderStatsPush
+type mutexSenderStats struct {
+	maxNodes int
+	lock     sync.Mutex
+	mapNodes map[string]*SenderStatsNode
+}
+
+func (stats *mutexSenderStats) Push(name string, bytes int) {
+	stats.lock.Lock()
+	defer stats.lock.Unlock()
+
+	node, isok := stats.mapNodes[name]
+	if !isok {
+		node = &SenderStatsNode{
+			Name:      name,
+			MinBytes:  math.MaxInt32,
+			Histogram: NewHistogram(DefaultHistogramAccuracy),
+		}
+
+		stats.mapNodes[name] = node
+	}
+
+	if bytes > node.MaxBytes {
+		node.MaxBytes = bytes
+	}
+
+	if bytes < node.MinBytes {
+		node.MinBytes = bytes
+	}
+
+	node.TotalTimes++
+	node.TotalBytes += int64(bytes)
+	node.Nodes = append(node.Nodes, bytes)
+
+	node.Histogram.Record(float64(bytes))
+
+	if len(node.Nodes) > stats.maxNodes*2 {
+		sort.Slice(node.Nodes, func(i, j int) bool {
+			return node.Nodes[i] > node.Nodes[j]
+		})
+
+		node.Nodes = node.Nodes[:stats.maxNodes]
+	}
+}
+
+// Benchmark_SenderStatsPush - n goroutines push to one name or to 16 names, path=mutex is the Push with one lock for comparison
+func Benchmark_SenderStatsPush(b *testing.B) {
+	for _, path := range []string{"atomic", "mutex"} {
+		for _, goroutines := range []int{1, 2, 4, 8, 16, 32, 64} {
+			for _, names := range []int{1, 16} {
+				b.Run(fmt.Sprintf("path=%v/goroutines=%v/names=%v", path, goroutines, names), func(b *testing.B) {
+					var push func(name string, bytes int)
+					if path == "mutex" {
+						push = (&mutexSenderStats{maxNodes: 100, mapNodes: make(map[string]*SenderStatsNode)}).Push
+					} else {
+						push = NewSenderStats(100, 0, time.Hour, b.TempDir(), "senderstats").Push
+					}
+
+					benchmarkSenderStatsPush(b, push, goroutines, names)
+				})
+			}
+		}
+	}
+}
+
+func benchmarkSenderStatsPush(b *testing.B, push func(name string, bytes int), goroutines int, names int) {
+	lstName := make([]string, names)
+	for i := range lstName {
+		lstName[i] = fmt.Sprintf("msg%v", i)
+		push(lstName[i], 1)
+	}
+
+	b.ResetTimer()
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			for j := i; j < b.N; j += goroutines {
+				push(lstName[j%names], j&0xffff)
+			}
+		}(i)
+	}
+
+	wg.Wait()
+}
@@ -1,11 +1,10 @@
 package goutils
 
 import (
-	"fmt"
+	"context"
 	"math"
-	"os"
-	"path"
 	"sort"
+	"strconv"
 	"sync"
 	"time"
 
@@ -18,23 +17,44 @@ type ServStatsMsgNode struct {
 }
 
 type ServStatsMsg struct {
-	Name         string     `json:"name,omitempty"`
-	TotalTime    float64    `json:"totalTime,omitempty"`
-	TotalTimes   int        `json:"totalTimes,omitempty"`
-	MaxTime      float64    `json:"maxTime,omitempty"`
-	MinTime      float64    `json:"minTime,omitempty"`
-	MaxParallels int        `json:"maxParallels,omitempty"`
-	Nodes        []float64  `json:"nodes,omitempty"`
-	pool         sync.Pool  `json:"-"`
-	lock         sync.Mutex `json:"-"`
-	LastMsgNums  int        `json:"lastMsgNums"`
-}
-
-func newServStatsMsg(name string, poolSize int) *ServStatsMsg {
+	Name         string                       `json:"name,omitempty"`
+	TotalTime    float64                      `json:"totalTime,omitempty"`
+	TotalTimes   int                          `json:"totalTimes,omitempty"`
+	MaxTime      float64                      `json:"maxTime,omitempty"`
+	MinTime      float64                      `json:"minTime,omitempty"`
+	MaxParallels int                          `json:"maxParallels,omitempty"`
+	Nodes        []float64                    `json:"nodes,omitempty"`
+	P50          float64                      `json:"p50,omitempty"`
+	P90          float64                      `json:"p90,omitempty"`
+	P99          float64                      `json:"p99,omitempty"`
+	P999         float64                      `json:"p999,omitempty"`
+	Histogram    *Histogram                   `json:"histogram,omitempty"`
+	Windows      []*WindowStats               `json:"windows,omitempty"`
+	ErrTimes     int                          `json:"errTimes,omitempty"`
+	ErrTotalTime float64                      `json:"errTotalTime,omitempty"`
+	Parents      map[string]int               `json:"parents,omitempty"`
+	Outcomes     map[string]*ServStatsOutcome `json:"outcomes,omitempty"`
+	pool         sync.Pool                    `json:"-"`
+	lock         sync.Mutex                   `json:"-"`
+	rolling      *RollingStats                `json:"-"`
+	cumulative   servStatsCumulative          `json:"-"`
+	LastMsgNums  int                          `json:"lastMsgNums"`
+}
+
+// servStatsCumulative - the counters cleared by ResetOnOutput, so the counters of PromHandler never decrease
+type servStatsCumulative struct {
+	times     int
+	histogram *Histogram
+	outcomes  map[string]*ServStatsOutcome
+}
+
+func newServStatsMsg(name string, poolSize int, histogramAccuracy float64, rolling *RollingStats) *ServStatsMsg {
 	msg := &ServStatsMsg{
-		Name:    name,
-		MaxTime: 0,
-		MinTime: math.MaxFloat64,
+		Name:      name,
+		MaxTime:   0,
+		MinTime:   math.MaxFloat64,
+		Histogram: NewHistogram(histogramAccuracy),
+		rolling:   rolling,
 	}
 
 	msg.pool = sync.Pool{
@@ -60,17 +80,153 @@ func (msg *ServStatsMsg) startMsg() *ServStatsMsgNode {
 	return n
 }
 
-func (msg *ServStatsMsg) sort() {
+// snapshot - sort Nodes, update the percentiles and windows, and return a copy for output.
+// If isReset is true, the counters are reset after copying.
+func (msg *ServStatsMsg) snapshot(now time.Time, windows []time.Duration, isReset bool) *ServStatsMsg {
 	msg.lock.Lock()
+	defer msg.lock.Unlock()
 
 	sort.Slice(msg.Nodes, func(i, j int) bool {
 		return msg.Nodes[i] > msg.Nodes[j]
 	})
 
-	msg.lock.Unlock()
+	msg.P50 = msg.Histogram.Percentile(50)
+	msg.P90 = msg.Histogram.Percentile(90)
+	msg.P99 = msg.Histogram.Percentile(99)
+	msg.P999 = msg.Histogram.Percentile(99.9)
+	msg.Windows = msg.rolling.queryWindows(now, windows)
+
+	snapshot := &ServStatsMsg{
+		Name:         msg.Name,
+		TotalTime:    msg.TotalTime,
+		TotalTimes:   msg.TotalTimes,
+		MaxTime:      msg.MaxTime,
+		MinTime:      msg.MinTime,
+		MaxParallels: msg.MaxParallels,
+		Nodes:        append([]float64{}, msg.Nodes...),
+		P50:          msg.P50,
+		P90:          msg.P90,
+		P99:          msg.P99,
+		P999:         msg.P999,
+		Histogram:    msg.Histogram.Clone(),
+		Windows:      msg.Windows,
+		ErrTimes:     msg.ErrTimes,
+		ErrTotalTime: msg.ErrTotalTime,
+		LastMsgNums:  msg.LastMsgNums,
+	}
+
+	if len(msg.Outcomes) > 0 {
+		snapshot.Outcomes = make(map[string]*ServStatsOutcome, len(msg.Outcomes))
+		for k, v := range msg.Outcomes {
+			snapshot.Outcomes[k] = v.clone()
+		}
+	}
+
+	if len(msg.Parents) > 0 {
+		snapshot.Parents = make(map[string]int, len(msg.Parents))
+		for k, v := range msg.Parents {
+			snapshot.Parents[k] = v
+		}
+	}
+
+	if isReset {
+		msg.reset()
+	}
+
+	return snapshot
+}
+
+// reset - clear the counters, messages in processing are kept, msg.lock must be locked.
+// The cleared counters are added into msg.cumulative.
+func (msg *ServStatsMsg) reset() {
+	msg.addCumulative()
+
+	msg.TotalTime = 0
+	msg.TotalTimes = 0
+	msg.MaxTime = 0
+	msg.MinTime = math.MaxFloat64
+	msg.MaxParallels = msg.LastMsgNums
+	msg.Nodes = nil
+	msg.P50 = 0
+	msg.P90 = 0
+	msg.P99 = 0
+	msg.P999 = 0
+	msg.ErrTimes = 0
+	msg.ErrTotalTime = 0
+	msg.Parents = nil
+	msg.Outcomes = nil
+	msg.Histogram.Reset()
 }
 
-func (msg *ServStatsMsg) endMsg(node *ServStatsMsgNode, maxNodes int) {
+// addCumulative - add TotalTimes, Histogram and Outcomes into msg.cumulative, msg.lock must be locked
+func (msg *ServStatsMsg) addCumulative() {
+	if msg.cumulative.histogram == nil {
+		msg.cumulative.histogram = NewHistogram(msg.Histogram.RelativeAccuracy)
+		msg.cumulative.outcomes = make(map[string]*ServStatsOutcome)
+	}
+
+	msg.cumulative.times += msg.TotalTimes
+	msg.cumulative.histogram.Merge(msg.Histogram)
+
+	for k, v := range msg.Outcomes {
+		outcome, isok := msg.cumulative.outcomes[k]
+		if !isok {
+			msg.cumulative.outcomes[k] = v.clone()
+
+			continue
+		}
+
+		outcome.merge(v)
+	}
+}
+
+// cumulativeCounters - TotalTimes, Histogram and Outcomes since the start, with the counters cleared by ResetOnOutput,
+// they are not copied without ResetOnOutput, msg.lock must be locked
+func (msg *ServStatsMsg) cumulativeCounters() (int, *Histogram, map[string]*ServStatsOutcome) {
+	if msg.cumulative.histogram == nil {
+		return msg.TotalTimes, msg.Histogram, msg.Outcomes
+	}
+
+	histogram := msg.cumulative.histogram.Clone()
+	histogram.Merge(msg.Histogram)
+
+	outcomes := make(map[string]*ServStatsOutcome, len(msg.cumulative.outcomes))
+	for k, v := range msg.cumulative.outcomes {
+		outcomes[k] = v.clone()
+	}
+
+	for k, v := range msg.Outcomes {
+		outcome, isok := outcomes[k]
+		if !isok {
+			outcomes[k] = v
+
+			continue
+		}
+
+		outcome.merge(v)
+	}
+
+	return msg.cumulative.times + msg.TotalTimes, histogram, outcomes
+}
+
+// Percentile - q is in [0, 100], the time is in seconds
+func (msg *ServStatsMsg) Percentile(q float64) float64 {
+	msg.lock.Lock()
+	defer msg.lock.Unlock()
+
+	return msg.Histogram.Percentile(q)
+}
+
+// BucketCounts - non-empty buckets of the time histogram
+func (msg *ServStatsMsg) BucketCounts() []*HistogramBucket {
+	msg.lock.Lock()
+	defer msg.lock.Unlock()
+
+	return msg.Histogram.BucketCounts()
+}
+
+// endMsg - err is classified by GetServStatsOutcome, parent is the name of the parent span
+func (msg *ServStatsMsg) endMsg(node *ServStatsMsgNode, maxNodes int, timer ITime, err error, parent string) {
 	node.End = time.Now()
 
 	dt := node.End.Sub(node.Start).Seconds()
@@ -88,6 +244,38 @@ func (msg *ServStatsMsg) endMsg(node *ServStatsMsgNode, maxNodes int) {
 	msg.TotalTime += dt
 	msg.TotalTimes++
 
+	msg.Histogram.Record(dt)
+
+	outcome := GetServStatsOutcome(err)
+	if outcome != ServStatsOutcomeOK {
+		msg.ErrTimes++
+		msg.ErrTotalTime += dt
+	}
+
+	if msg.Outcomes == nil {
+		msg.Outcomes = make(map[string]*ServStatsOutcome)
+	}
+
+	o, isok := msg.Outcomes[outcome]
+	if !isok {
+		o = newServStatsOutcome(msg.Histogram.RelativeAccuracy)
+		msg.Outcomes[outcome] = o
+	}
+
+	o.record(dt)
+
+	if parent != "" {
+		if msg.Parents == nil {
+			msg.Parents = make(map[string]int)
+		}
+
+		msg.Parents[parent]++
+	}
+
+	if msg.rolling != nil {
+		msg.rolling.Record(timer.Now(), dt)
+	}
+
 	msg.Nodes = append(msg.Nodes, dt)
 	if len(msg.Nodes) > maxNodes*2 {
 		sort.Slice(msg.Nodes, func(i, j int) bool {
@@ -105,41 +293,140 @@ func (msg *ServStatsMsg) endMsg(node *ServStatsMsgNode, maxNodes int) {
 }
 
 type ServStats struct {
-	MapMsgs      map[string]*ServStatsMsg `json:"mapMsgs,omitempty"`
-	MaxNodes     int                      `json:"-"`
-	ChanState    chan int                 `json:"-"`
-	TickerOutput *time.Ticker             `json:"-"`
-	PathOutput   string                   `json:"-"`
-	poolSize     int                      `json:"-"`
-	prefixFN     string                   `json:"-"`
+	Type              string                   `json:"type"`
+	Instance          string                   `json:"instance,omitempty"`
+	MapMsgs           map[string]*ServStatsMsg `json:"mapMsgs,omitempty"`
+	Runtime           *RuntimeStats            `json:"runtime,omitempty"`
+	MaxNodes          int                      `json:"-"`
+	ChanState         chan int                 `json:"-"`
+	TickerOutput      *time.Ticker             `json:"-"`
+	PathOutput        string                   `json:"-"`
+	HistogramAccuracy float64                  `json:"-"`
+	AutoRegMsg        bool                     `json:"-"`
+	WindowSlot        time.Duration            `json:"-"`
+	Windows           []time.Duration          `json:"-"`
+	ResetOnOutput     bool                     `json:"-"`
+	StartTs           int64                    `json:"startTs"`
+	EndTs             int64                    `json:"endTs"`
+	poolSize          int                      `json:"-"`
+	prefixFN          string                   `json:"-"`
+	timer             ITime                    `json:"-"`
+	sinks             []StatsSink              `json:"-"`
+	runtimeStats      *RuntimeStats            `json:"-"`
+	loop              *statsLoop               `json:"-"`
+	lock              sync.RWMutex             `json:"-"`
 }
 
 func NewServStats(maxNodes int, chanSize int, outputTimer time.Duration, pathOutput string, poolSize int, prefixFN string) *ServStats {
 	stats := &ServStats{
-		MapMsgs:      make(map[string]*ServStatsMsg),
-		MaxNodes:     maxNodes,
-		ChanState:    make(chan int),
-		TickerOutput: time.NewTicker(outputTimer),
-		PathOutput:   pathOutput,
-		poolSize:     poolSize,
-		prefixFN:     prefixFN,
+		Type:              StatsSnapshotTypeServ,
+		Instance:          defaultStatsInstance(),
+		MapMsgs:           make(map[string]*ServStatsMsg),
+		MaxNodes:          maxNodes,
+		ChanState:         make(chan int, chanSize),
+		TickerOutput:      time.NewTicker(outputTimer),
+		PathOutput:        pathOutput,
+		HistogramAccuracy: DefaultHistogramAccuracy,
+		poolSize:          poolSize,
+		prefixFN:          prefixFN,
+		timer:             gTime,
+		sinks:             []StatsSink{NewFileStatsSink(pathOutput, 0, 0)},
+		loop:              newStatsLoop("ServStats"),
 	}
 
+	stats.StartTs = stats.timer.Now().Unix()
+
 	return stats
 }
 
-func (stats *ServStats) Start() {
-	go stats.mainLoop()
+// SetTimer - the clock of the windows and the output timestamps
+func (stats *ServStats) SetTimer(timer ITime) {
+	stats.lock.Lock()
+	stats.timer = timer
+	stats.StartTs = timer.Now().Unix()
+	stats.lock.Unlock()
+}
+
+// SetInstance - the instance name in the dumps, default is the hostname, see LoadStatsSnapshots
+func (stats *ServStats) SetInstance(instance string) {
+	stats.lock.Lock()
+	stats.Instance = instance
+	stats.lock.Unlock()
+}
+
+// AddSink - add an output target, a FileStatsSink of pathOutput is added by NewServStats
+func (stats *ServStats) AddSink(sink StatsSink) {
+	stats.lock.Lock()
+	stats.sinks = append(stats.sinks, sink)
+	stats.lock.Unlock()
 }
 
+// SetSinks - replace all output targets
+func (stats *ServStats) SetSinks(sinks ...StatsSink) {
+	stats.lock.Lock()
+	stats.sinks = sinks
+	stats.lock.Unlock()
+}
+
+// SetRuntimeStats - the metrics of runtimeStats are written into every Output of stats as runtime,
+// and runtimeStats does not output by itself any more, it still samples after RuntimeStats.Start
+func (stats *ServStats) SetRuntimeStats(runtimeStats *RuntimeStats) {
+	runtimeStats.attach()
+
+	stats.lock.Lock()
+	stats.runtimeStats = runtimeStats
+	stats.lock.Unlock()
+}
+
+// SetWindows - keep rolling windows like 1m / 5m / 15m with ring-buffered slots, only for messages registered later
+func (stats *ServStats) SetWindows(slot time.Duration, windows ...time.Duration) {
+	stats.lock.Lock()
+	stats.WindowSlot = slot
+	stats.Windows = windows
+	stats.lock.Unlock()
+}
+
+// SetResetOnOutput - if isReset is true, the counters are reset after every Output
+func (stats *ServStats) SetResetOnOutput(isReset bool) {
+	stats.lock.Lock()
+	stats.ResetOnOutput = isReset
+	stats.lock.Unlock()
+}
+
+// SetHistogramAccuracy - relative accuracy of the time histogram, like 0.01, only for messages registered later
+func (stats *ServStats) SetHistogramAccuracy(accuracy float64) {
+	stats.lock.Lock()
+	stats.HistogramAccuracy = accuracy
+	stats.lock.Unlock()
+}
+
+// SetAutoRegMsg - if isAuto is true, StartMsg registers unknown messages
+func (stats *ServStats) SetAutoRegMsg(isAuto bool) {
+	stats.lock.Lock()
+	stats.AutoRegMsg = isAuto
+	stats.lock.Unlock()
+}
+
+// Start - start the output goroutine, it outputs on every tick until ctx is done or Stop is called.
+// It returns ErrAlreadyStarted if it is called twice.
+func (stats *ServStats) Start(ctx context.Context) error {
+	return stats.loop.start(ctx, stats.TickerOutput, stats.ChanState, stats.Output)
+}
+
+// Stop - stop the output goroutine and flush a final Output, it can be called more than once or without Start
 func (stats *ServStats) Stop() {
-	stats.TickerOutput.Stop()
-	stats.ChanState <- 0
+	stats.loop.stop(stats.TickerOutput, stats.Output)
+}
+
+// Wait - wait for the output goroutine to exit, like after ctx is done
+func (stats *ServStats) Wait() {
+	stats.loop.wait()
 }
 
+// StartMsg - returns nil if msgname is not registered and AutoRegMsg is false
 func (stats *ServStats) StartMsg(msgname string) *ServStatsMsgNode {
-	msg, isok := stats.MapMsgs[msgname]
-	if isok {
+	msg := stats.getMsg(msgname)
+	if msg != nil {
 		return msg.startMsg()
 	}
 
@@ -147,49 +434,171 @@ func (stats *ServStats) StartMsg(msgname string) *ServStatsMsgNode {
 }
 
 func (stats *ServStats) EndMsg(msgname string, node *ServStatsMsgNode) {
+	stats.EndMsgWithErr(msgname, node, nil)
+}
+
+// EndMsgWithErr - like EndMsg, err is counted by its outcome, see GetServStatsOutcome
+func (stats *ServStats) EndMsgWithErr(msgname string, node *ServStatsMsgNode, err error) {
+	if node == nil {
+		return
+	}
+
+	stats.lock.RLock()
 	msg, isok := stats.MapMsgs[msgname]
+	timer := stats.timer
+	stats.lock.RUnlock()
+
 	if isok {
-		msg.endMsg(node, stats.MaxNodes)
+		msg.endMsg(node, stats.MaxNodes, timer, err, "")
 	}
 }
 
+// RegMsg - register a message, a registered message is kept
 func (stats *ServStats) RegMsg(name string) {
-	stats.MapMsgs[name] = newServStatsMsg(name, stats.poolSize)
+	stats.lock.Lock()
+	defer stats.lock.Unlock()
+
+	stats.regMsg(name)
+}
+
+// regMsg - stats.lock must be locked
+func (stats *ServStats) regMsg(name string) *ServStatsMsg {
+	msg, isok := stats.MapMsgs[name]
+	if !isok {
+		msg = newServStatsMsg(name, stats.poolSize, stats.HistogramAccuracy, newRollingStatsWithWindows(stats.WindowSlot, stats.Windows))
+		stats.MapMsgs[name] = msg
+	}
+
+	return msg
+}
+
+// getMsg - returns nil if name is not registered and AutoRegMsg is false
+func (stats *ServStats) getMsg(name string) *ServStatsMsg {
+	stats.lock.RLock()
+	msg, isok := stats.MapMsgs[name]
+	isAuto := stats.AutoRegMsg
+	stats.lock.RUnlock()
+
+	if isok {
+		return msg
+	}
+
+	if !isAuto {
+		return nil
+	}
+
+	stats.lock.Lock()
+	defer stats.lock.Unlock()
+
+	return stats.regMsg(name)
+}
+
+// sortedMsgs - all messages sorted by name
+func (stats *ServStats) sortedMsgs() []*ServStatsMsg {
+	stats.lock.RLock()
+	lst := make([]*ServStatsMsg, 0, len(stats.MapMsgs))
+	for _, v := range stats.MapMsgs {
+		lst = append(lst, v)
+	}
+	stats.lock.RUnlock()
+
+	sort.Slice(lst, func(i, j int) bool {
+		return lst[i].Name < lst[j].Name
+	})
+
+	return lst
 }
 
 func (stats *ServStats) Output() {
 	json := jsoniter.ConfigCompatibleWithStandardLibrary
 
+	stats.lock.Lock()
+	now := stats.timer.Now()
+	windows := stats.Windows
+	isReset := stats.ResetOnOutput
+
+	// the messages are copied under their locks, so marshaling does not race with StartMsg / EndMsg
+	output := &ServStats{
+		Type:     stats.Type,
+		Instance: stats.Instance,
+		MapMsgs:  make(map[string]*ServStatsMsg),
+		StartTs:  stats.StartTs,
+		EndTs:    now.Unix(),
+	}
+
 	for _, v := range stats.MapMsgs {
-		v.sort()
+		output.MapMsgs[v.Name] = v.snapshot(now, windows, isReset)
 	}
 
-	b, err := json.Marshal(stats)
-	if err != nil {
-		Warn("ServStats.output:Marshal",
-			Err(err))
+	stats.EndTs = output.EndTs
+	if isReset {
+		stats.StartTs = output.EndTs
+	}
 
-		return
+	sinks := stats.sinks
+	runtimeStats := stats.runtimeStats
+	stats.lock.Unlock()
+
+	if runtimeStats != nil {
+		output.Runtime = runtimeStats.outputAttached()
 	}
 
-	err = os.WriteFile(path.Join(stats.PathOutput, fmt.Sprintf("%v.%v.json", stats.prefixFN, time.Now().Unix())), b, 0644)
+	b, err := json.Marshal(output)
 	if err != nil {
-		Warn("ServStats.output:WriteFile",
+		Warn("ServStats.output:Marshal",
 			Err(err))
 
 		return
 	}
+
+	writeStatsDump("ServStats.output:Write", sinks, &StatsDump{
+		Prefix: stats.prefixFN,
+		Time:   now,
+		Data:   b,
+		Header: servStatsCSVHeader,
+		Rows:   output.csvRows(),
+	})
 }
 
-func (stats *ServStats) mainLoop() {
-	for {
-		select {
-		case <-stats.ChanState:
-			Info("ServStats:mainLoop:ChanState")
+var servStatsCSVHeader = []string{"name", "totalTimes", "totalTime", "avgTime", "minTime", "maxTime",
+	"p50", "p90", "p99", "p999", "errTimes", "maxParallels", "lastMsgNums"}
+
+// csvRows - one row per message, sorted by name
+func (stats *ServStats) csvRows() [][]string {
+	names := make([]string, 0, len(stats.MapMsgs))
+	for k := range stats.MapMsgs {
+		names = append(names, k)
+	}
+
+	sort.Strings(names)
 
-			return
-		case <-stats.TickerOutput.C:
-			stats.Output()
+	rows := [][]string{}
+	for _, name := range names {
+		msg := stats.MapMsgs[name]
+
+		avgTime := 0.0
+		minTime := 0.0
+		if msg.TotalTimes > 0 {
+			avgTime = msg.TotalTime / float64(msg.TotalTimes)
+			minTime = msg.MinTime
 		}
+
+		rows = append(rows, []string{
+			msg.Name,
+			strconv.Itoa(msg.TotalTimes),
+			formatStatsFloat(msg.TotalTime),
+			formatStatsFloat(avgTime),
+			formatStatsFloat(minTime),
+			formatStatsFloat(msg.MaxTime),
+			formatStatsFloat(msg.P50),
+			formatStatsFloat(msg.P90),
+			formatStatsFloat(msg.P99),
+			formatStatsFloat(msg.P999),
+			strconv.Itoa(msg.ErrTimes),
+			strconv.Itoa(msg.MaxParallels),
+			strconv.Itoa(msg.LastMsgNums),
+		})
 	}
+
+	return rows
 }
@@ -0,0 +1,123 @@
+package goutils
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net"
+	"os"
+)
+
+const (
+	// ServStatsOutcomeOK - err is nil
+	ServStatsOutcomeOK = "ok"
+	// ServStatsOutcomeError - err is not nil
+	ServStatsOutcomeError = "error"
+	// ServStatsOutcomeTimeout - err is context.DeadlineExceeded, os.ErrDeadlineExceeded or a net.Error timeout
+	ServStatsOutcomeTimeout = "timeout"
+)
+
+// OutcomeError - an error with a custom outcome label, see WithOutcome
+type OutcomeError struct {
+	Outcome string
+	Err     error
+}
+
+func (err *OutcomeError) Error() string {
+	if err.Err == nil {
+		return err.Outcome
+	}
+
+	return err.Outcome + ": " + err.Err.Error()
+}
+
+func (err *OutcomeError) Unwrap() error {
+	return err.Err
+}
+
+// WithOutcome - label err with outcome, err can be nil, like WithOutcome(nil, "cancelled")
+func WithOutcome(err error, outcome string) error {
+	return &OutcomeError{
+		Outcome: outcome,
+		Err:     err,
+	}
+}
+
+// GetServStatsOutcome - nil is ok, OutcomeError is its label, timeout errors are timeout, others are error
+func GetServStatsOutcome(err error) string {
+	if err == nil {
+		return ServStatsOutcomeOK
+	}
+
+	var outcomeErr *OutcomeError
+	if errors.As(err, &outcomeErr) {
+		return outcomeErr.Outcome
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, os.ErrDeadlineExceeded) {
+		return ServStatsOutcomeTimeout
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return ServStatsOutcomeTimeout
+	}
+
+	return ServStatsOutcomeError
+}
+
+// ServStatsOutcome - counts and latency of an outcome
+type ServStatsOutcome struct {
+	Times     int        `json:"times"`
+	TotalTime float64    `json:"totalTime"`
+	MaxTime   float64    `json:"maxTime"`
+	MinTime   float64    `json:"minTime"`
+	Histogram *Histogram `json:"histogram,omitempty"`
+}
+
+func newServStatsOutcome(histogramAccuracy float64) *ServStatsOutcome {
+	return &ServStatsOutcome{
+		MinTime:   math.MaxFloat64,
+		Histogram: NewHistogram(histogramAccuracy),
+	}
+}
+
+func (outcome *ServStatsOutcome) record(dt float64) {
+	outcome.Times++
+	outcome.TotalTime += dt
+
+	if dt > outcome.MaxTime {
+		outcome.MaxTime = dt
+	}
+
+	if dt < outcome.MinTime {
+		outcome.MinTime = dt
+	}
+
+	outcome.Histogram.Record(dt)
+}
+
+func (outcome *ServStatsOutcome) merge(o *ServStatsOutcome) {
+	outcome.Times += o.Times
+	outcome.TotalTime += o.TotalTime
+
+	if o.MaxTime > outcome.MaxTime {
+		outcome.MaxTime = o.MaxTime
+	}
+
+	if o.MinTime < outcome.MinTime {
+		outcome.MinTime = o.MinTime
+	}
+
+	outcome.Histogram.Merge(o.Histogram)
+}
+
+func (outcome *ServStatsOutcome) clone() *ServStatsOutcome {
+	return &ServStatsOutcome{
+		Times:     outcome.Times,
+		TotalTime: outcome.TotalTime,
+		MaxTime:   outcome.MaxTime,
+		MinTime:   outcome.MinTime,
+		Histogram: outcome.Histogram.Clone(),
+	}
+}
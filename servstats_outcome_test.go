@@ -0,0 +1,79 @@
+package goutils
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_GetServStatsOutcome(t *testing.T) {
+	assert.Equal(t, GetServStatsOutcome(nil), ServStatsOutcomeOK)
+	assert.Equal(t, GetServStatsOutcome(errors.New("err")), ServStatsOutcomeError)
+	assert.Equal(t, GetServStatsOutcome(context.DeadlineExceeded), ServStatsOutcomeTimeout)
+	assert.Equal(t, GetServStatsOutcome(fmt.Errorf("query: %w", os.ErrDeadlineExceeded)), ServStatsOutcomeTimeout)
+	assert.Equal(t, GetServStatsOutcome(WithOutcome(nil, "cancelled")), "cancelled")
+	assert.Equal(t, GetServStatsOutcome(fmt.Errorf("spin: %w", WithOutcome(context.DeadlineExceeded, "nomoney"))), "nomoney")
+
+	err := WithOutcome(context.DeadlineExceeded, "slow")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, err.Error(), "slow: context deadline exceeded")
+
+	t.Logf("Test_GetServStatsOutcome OK")
+}
+
+func Test_ServStatsOutcomes(t *testing.T) {
+	dir := t.TempDir()
+
+	stats := NewServStats(10, 0, time.Hour, dir, 16, "servstats")
+	stats.RegMsg("spin")
+
+	stats.EndMsg("spin", stats.StartMsg("spin"))
+	stats.EndMsgWithErr("spin", stats.StartMsg("spin"), nil)
+	stats.EndMsgWithErr("spin", stats.StartMsg("spin"), errors.New("err"))
+	stats.EndMsgWithErr("spin", stats.StartMsg("spin"), context.DeadlineExceeded)
+
+	_, done := stats.Track(context.Background(), "spin")
+	done(WithOutcome(nil, "nomoney"))
+
+	msg := stats.MapMsgs["spin"]
+	assert.Equal(t, msg.TotalTimes, 5)
+	assert.Equal(t, msg.ErrTimes, 3)
+	assert.Equal(t, msg.Outcomes[ServStatsOutcomeOK].Times, 2)
+	assert.Equal(t, msg.Outcomes[ServStatsOutcomeError].Times, 1)
+	assert.Equal(t, msg.Outcomes[ServStatsOutcomeTimeout].Times, 1)
+	assert.Equal(t, msg.Outcomes["nomoney"].Times, 1)
+	assert.Equal(t, msg.Outcomes["nomoney"].Histogram.Count, int64(1))
+
+	stats.Output()
+
+	files, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, len(files), 1)
+
+	data, err := os.ReadFile(path.Join(dir, files[0].Name()))
+	assert.NoError(t, err)
+
+	output := &ServStats{}
+	assert.NoError(t, jsoniter.ConfigCompatibleWithStandardLibrary.Unmarshal(data, output))
+	assert.Equal(t, output.MapMsgs["spin"].Outcomes[ServStatsOutcomeOK].Times, 2)
+	assert.Equal(t, output.MapMsgs["spin"].Outcomes["nomoney"].Times, 1)
+
+	handler := NewPromHandler("", nil)
+	handler.AddServStats(stats)
+
+	buf := &bytes.Buffer{}
+	assert.NoError(t, handler.Render(buf))
+	assert.Contains(t, buf.String(), `servstats_outcomes_total{stats="servstats",name="spin",outcome="ok"} 2`)
+	assert.Contains(t, buf.String(), `servstats_outcomes_total{stats="servstats",name="spin",outcome="timeout"} 1`)
+	assert.Contains(t, buf.String(), `servstats_outcome_latency_seconds_count{stats="servstats",name="spin",outcome="nomoney"} 1`)
+
+	t.Logf("Test_ServStatsOutcomes OK")
+}
@@ -0,0 +1,124 @@
+package goutils
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ServStatsPercentile(t *testing.T) {
+	stats := NewServStats(10, 0, time.Hour, "./", 16, "servstats")
+	stats.SetHistogramAccuracy(0.01)
+	stats.RegMsg("msg")
+
+	msg := stats.MapMsgs["msg"]
+	for i := 1; i <= 100; i++ {
+		node := stats.StartMsg("msg")
+		node.Start = time.Now().Add(-time.Duration(i) * time.Millisecond)
+		stats.EndMsg("msg", node)
+	}
+
+	assert.Equal(t, msg.TotalTimes, 100)
+	assert.InDelta(t, msg.Percentile(50), 0.050, 0.002)
+	assert.InDelta(t, msg.Percentile(99), 0.099, 0.002)
+	assert.True(t, len(msg.BucketCounts()) > 0)
+
+	snapshot := msg.snapshot(time.Now(), nil, false)
+	assert.InDelta(t, snapshot.P50, 0.050, 0.002)
+	assert.InDelta(t, snapshot.P90, 0.090, 0.002)
+	assert.InDelta(t, snapshot.P99, 0.099, 0.002)
+	assert.InDelta(t, snapshot.P999, 0.100, 0.002)
+	assert.Equal(t, snapshot.Nodes[0] >= snapshot.Nodes[1], true)
+	assert.Equal(t, snapshot.Histogram.Count, int64(100))
+
+	t.Logf("Test_ServStatsPercentile OK")
+}
+
+func Test_ServStatsAutoReg(t *testing.T) {
+	stats := NewServStats(10, 0, time.Hour, "./", 16, "servstats")
+
+	assert.Nil(t, stats.StartMsg("msg"))
+	stats.EndMsg("msg", nil)
+
+	stats.SetAutoRegMsg(true)
+
+	node := stats.StartMsg("msg")
+	assert.NotNil(t, node)
+	stats.EndMsg("msg", node)
+
+	// RegMsg keeps a registered message
+	stats.RegMsg("msg")
+	assert.Equal(t, stats.MapMsgs["msg"].TotalTimes, 1)
+
+	t.Logf("Test_ServStatsAutoReg OK")
+}
+
+// Test_ServStatsRace - run with go test -race
+func Test_ServStatsRace(t *testing.T) {
+	dir := t.TempDir()
+
+	stats := NewServStats(10, 0, time.Hour, dir, 16, "servstats")
+	stats.SetAutoRegMsg(true)
+	stats.RegMsg("msg0")
+
+	handler := NewPromHandler("race", nil)
+	handler.AddServStats(stats)
+
+	wg := sync.WaitGroup{}
+
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			for j := 0; j < 500; j++ {
+				name := fmt.Sprintf("msg%v", (i+j)%5)
+
+				node := stats.StartMsg(name)
+				stats.EndMsg(name, node)
+			}
+		}(i)
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		for j := 0; j < 50; j++ {
+			stats.RegMsg(fmt.Sprintf("reg%v", j))
+		}
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		for j := 0; j < 10; j++ {
+			stats.Output()
+			handler.Render(io.Discard)
+		}
+	}()
+
+	wg.Wait()
+
+	total := 0
+	for _, msg := range stats.sortedMsgs() {
+		total += msg.TotalTimes
+		assert.Equal(t, msg.LastMsgNums, 0)
+	}
+
+	assert.Equal(t, total, 8*500)
+	assert.Equal(t, len(stats.MapMsgs), 55)
+
+	files, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.True(t, len(files) > 0)
+
+	t.Logf("Test_ServStatsRace OK")
+}
@@ -0,0 +1,164 @@
+package goutils
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+type servStatsSpanKey struct{}
+
+// ServStatsSpan - a span started by ServStats.Track
+type ServStatsSpan struct {
+	Name   string
+	Parent *ServStatsSpan
+	Start  time.Time
+}
+
+// Path - names from the root span, like "login/loadplayer/query"
+func (span *ServStatsSpan) Path() string {
+	if span.Parent == nil {
+		return span.Name
+	}
+
+	return span.Parent.Path() + "/" + span.Name
+}
+
+// SpanFromContext - returns nil if there is no span in ctx
+func SpanFromContext(ctx context.Context) *ServStatsSpan {
+	span, _ := ctx.Value(servStatsSpanKey{}).(*ServStatsSpan)
+
+	return span
+}
+
+// FuncServStatsDone - ends a span, err is nil if it is ok
+type FuncServStatsDone func(err error)
+
+// FuncServStatsHTTPName - returns the message name of a http request
+type FuncServStatsHTTPName func(r *http.Request) string
+
+func onServStatsDoneNothing(err error) {
+}
+
+// Track - start a span named name, the span of ctx is its parent.
+// done must be called once when it is finished, calling it again does nothing.
+// If name is not registered and AutoRegMsg is false, ctx is returned and done does nothing,
+// it is logged at the debug level, because the names of HTTPMiddleware come from the requests.
+func (stats *ServStats) Track(ctx context.Context, name string) (context.Context, FuncServStatsDone) {
+	msg := stats.getMsg(name)
+	if msg == nil {
+		Debug("ServStats.Track",
+			slog.String("name", name),
+			Err(ErrNoMsgName))
+
+		return ctx, onServStatsDoneNothing
+	}
+
+	parent := SpanFromContext(ctx)
+	parentName := ""
+	if parent != nil {
+		parentName = parent.Name
+	}
+
+	node := msg.startMsg()
+
+	span := &ServStatsSpan{
+		Name:   name,
+		Parent: parent,
+		Start:  node.Start,
+	}
+
+	var isDone atomic.Bool
+
+	return context.WithValue(ctx, servStatsSpanKey{}, span), func(err error) {
+		if isDone.Swap(true) {
+			return
+		}
+
+		stats.lock.RLock()
+		timer := stats.timer
+		stats.lock.RUnlock()
+
+		msg.endMsg(node, stats.MaxNodes, timer, err, parentName)
+	}
+}
+
+// TrackUnary - tracks a gRPC-style unary call, handler has the same signature as grpc.UnaryHandler.
+// If handler panics, the call is ended with ErrTrackPanic and the panic goes on.
+//
+//	func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+//		return stats.TrackUnary(ctx, info.FullMethod, req, handler)
+//	}
+func (stats *ServStats) TrackUnary(ctx context.Context, name string, req interface{}, handler func(ctx context.Context, req interface{}) (interface{}, error)) (interface{}, error) {
+	ctx, done := stats.Track(ctx, name)
+
+	err := ErrTrackPanic
+	defer func() {
+		done(err)
+	}()
+
+	ret, err := handler(ctx, req)
+
+	return ret, err
+}
+
+// HTTPMiddleware - tracks every request, a response with status >= 500 is an error.
+// funcName returns the message name, r.URL.Path is used if it is nil.
+// If next panics, the request is ended with ErrTrackPanic and the panic goes on.
+func (stats *ServStats) HTTPMiddleware(funcName FuncServStatsHTTPName, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := r.URL.Path
+		if funcName != nil {
+			name = funcName(r)
+		}
+
+		ctx, done := stats.Track(r.Context(), name)
+
+		err := ErrTrackPanic
+		defer func() {
+			done(err)
+		}()
+
+		sw := &servStatsResponseWriter{
+			ResponseWriter: w,
+			status:         http.StatusOK,
+		}
+
+		next.ServeHTTP(sw, r.WithContext(ctx))
+
+		err = nil
+		if sw.status >= http.StatusInternalServerError {
+			err = fmt.Errorf("%w: %v", ErrHTTPServerError, sw.status)
+		}
+	})
+}
+
+// servStatsResponseWriter - records the status code
+type servStatsResponseWriter struct {
+	http.ResponseWriter
+	status      int
+	isWroteHead bool
+}
+
+func (sw *servStatsResponseWriter) WriteHeader(status int) {
+	if !sw.isWroteHead {
+		sw.status = status
+		sw.isWroteHead = true
+	}
+
+	sw.ResponseWriter.WriteHeader(status)
+}
+
+func (sw *servStatsResponseWriter) Write(b []byte) (int, error) {
+	sw.isWroteHead = true
+
+	return sw.ResponseWriter.Write(b)
+}
+
+// Unwrap - for http.ResponseController
+func (sw *servStatsResponseWriter) Unwrap() http.ResponseWriter {
+	return sw.ResponseWriter
+}
@@ -0,0 +1,104 @@
+package goutils
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ServStatsTrack(t *testing.T) {
+	stats := NewServStats(10, 0, time.Hour, "./", 16, "servstats")
+	stats.RegMsg("login")
+	stats.RegMsg("loadplayer")
+
+	ctx, done := stats.Track(context.Background(), "login")
+	span := SpanFromContext(ctx)
+	assert.NotNil(t, span)
+	assert.Equal(t, span.Path(), "login")
+
+	cctx, cdone := stats.Track(ctx, "loadplayer")
+	assert.Equal(t, SpanFromContext(cctx).Path(), "login/loadplayer")
+	cdone(errors.New("db"))
+	cdone(nil)
+
+	done(nil)
+
+	login := stats.MapMsgs["login"]
+	assert.Equal(t, login.TotalTimes, 1)
+	assert.Equal(t, login.ErrTimes, 0)
+	assert.Equal(t, login.LastMsgNums, 0)
+
+	loadplayer := stats.MapMsgs["loadplayer"]
+	assert.Equal(t, loadplayer.TotalTimes, 1)
+	assert.Equal(t, loadplayer.ErrTimes, 1)
+	assert.Equal(t, loadplayer.Parents["login"], 1)
+
+	// unregistered
+	nctx, ndone := stats.Track(ctx, "unknown")
+	assert.Equal(t, nctx, ctx)
+	ndone(nil)
+
+	ret, err := stats.TrackUnary(context.Background(), "login", 1, func(ctx context.Context, req interface{}) (interface{}, error) {
+		assert.Equal(t, SpanFromContext(ctx).Name, "login")
+
+		return req.(int) + 1, nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, ret, 2)
+	assert.Equal(t, login.TotalTimes, 2)
+
+	// a panic ends the call with ErrTrackPanic
+	assert.Panics(t, func() {
+		stats.TrackUnary(context.Background(), "login", 1, func(ctx context.Context, req interface{}) (interface{}, error) {
+			panic("boom")
+		})
+	})
+	assert.Equal(t, login.TotalTimes, 3)
+	assert.Equal(t, login.ErrTimes, 1)
+	assert.Equal(t, login.LastMsgNums, 0)
+
+	t.Logf("Test_ServStatsTrack OK")
+}
+
+func Test_ServStatsHTTPMiddleware(t *testing.T) {
+	stats := NewServStats(10, 0, time.Hour, "./", 16, "servstats")
+	stats.SetAutoRegMsg(true)
+
+	handler := stats.HTTPMiddleware(nil, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, SpanFromContext(r.Context()).Name, r.URL.Path)
+
+		if r.URL.Path == "/panic" {
+			panic("boom")
+		}
+
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+
+		w.Write([]byte("ok"))
+	}))
+
+	for _, p := range []string{"/ok", "/ok", "/fail"} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, p, nil))
+	}
+
+	assert.Equal(t, stats.MapMsgs["/ok"].TotalTimes, 2)
+	assert.Equal(t, stats.MapMsgs["/ok"].ErrTimes, 0)
+	assert.Equal(t, stats.MapMsgs["/fail"].TotalTimes, 1)
+	assert.Equal(t, stats.MapMsgs["/fail"].ErrTimes, 1)
+
+	assert.Panics(t, func() {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/panic", nil))
+	})
+	assert.Equal(t, stats.MapMsgs["/panic"].TotalTimes, 1)
+	assert.Equal(t, stats.MapMsgs["/panic"].ErrTimes, 1)
+	assert.Equal(t, stats.MapMsgs["/panic"].LastMsgNums, 0)
+
+	t.Logf("Test_ServStatsHTTPMiddleware OK")
+}
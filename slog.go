@@ -3,13 +3,10 @@ package goutils
 import (
 	"context"
 	"fmt"
-	"os"
 	"path"
 	"strings"
 
 	"log/slog"
-
-	"gopkg.in/natefinch/lumberjack.v2"
 )
 
 func parseLevel(str string) slog.Level {
@@ -26,29 +23,52 @@ func parseLevel(str string) slog.Level {
 	return slog.LevelInfo
 }
 
+// InitLogger2 - set slog.Default, it logs json into stderr if isConsole is true, or into <logpath>/<appName>.log
 func InitLogger2(appName string, appVersion string, strLevel string, isConsole bool, logpath string) {
-	lv := parseLevel(strLevel)
-
-	var lvl = &slog.LevelVar{}
-	lvl.Set(lv)
-	opts := &slog.HandlerOptions{
-		Level: lvl,
+	cfg := &LoggerConfig{
+		Level:  parseLevel(strLevel).String(),
+		Format: LoggerFormatJSON,
 	}
 
-	if isConsole {
-		slog.SetDefault(slog.New(slog.NewJSONHandler(os.Stderr, opts)))
-	} else {
-		logger := &lumberjack.Logger{
-			Filename:   path.Join(logpath, fmt.Sprintf("%v.log", appName)),
-			MaxBackups: 99,
-			MaxAge:     30,   //days
-			Compress:   true, // disabled by default
+	if !isConsole {
+		cfg.Outputs = []*LoggerOutput{
+			{
+				Type:       LoggerOutputFile,
+				Filename:   path.Join(logpath, fmt.Sprintf("%v.log", appName)),
+				MaxBackups: 99,
+				MaxAge:     30, //days
+				Compress:   true,
+			},
 		}
+	}
 
-		slog.SetDefault(slog.New(slog.NewJSONHandler(logger, opts)))
+	err := InitLoggerWithConfig(cfg)
+	if err != nil {
+		Error("InitLogger2:InitLoggerWithConfig",
+			Err(err))
 	}
 }
 
+// DebugCtx logs a debug message with the given fields and the attrs of ctx, see WithLogAttrs
+func DebugCtx(ctx context.Context, message string, fields ...slog.Attr) {
+	slog.LogAttrs(ctx, slog.LevelDebug, message, fields...)
+}
+
+// InfoCtx logs an info message with the given fields and the attrs of ctx, see WithLogAttrs
+func InfoCtx(ctx context.Context, message string, fields ...slog.Attr) {
+	slog.LogAttrs(ctx, slog.LevelInfo, message, fields...)
+}
+
+// WarnCtx logs a warn message with the given fields and the attrs of ctx, see WithLogAttrs
+func WarnCtx(ctx context.Context, message string, fields ...slog.Attr) {
+	slog.LogAttrs(ctx, slog.LevelWarn, message, fields...)
+}
+
+// ErrorCtx logs an error message with the given fields and the attrs of ctx, see WithLogAttrs
+func ErrorCtx(ctx context.Context, message string, fields ...slog.Attr) {
+	slog.LogAttrs(ctx, slog.LevelError, message, fields...)
+}
+
 // Debug logs a debug message with the given fields
 func Debug(message string, fields ...slog.Attr) {
 	slog.LogAttrs(context.Background(), slog.LevelDebug, message, fields...)
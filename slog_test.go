@@ -46,3 +46,20 @@ func Test_InitLogger2(t *testing.T) {
 
 	t.Logf("Test_InitLogger2 OK")
 }
+
+func Test_LogFuncs(t *testing.T) {
+	h := CaptureLogs(t)
+
+	Debug("debug", slog.Int("n", 1))
+	Info("info", slog.Int("n", 2))
+	Warn("warn", slog.Int("n", 3))
+	Error("error", slog.Int("n", 4), Err(ErrDuplicateMsgCtx))
+
+	records := h.Records()
+	assert.Equal(t, records.Messages(), []string{"debug", "info", "warn", "error"})
+	assert.Equal(t, records.ByLevel(slog.LevelWarn).Messages(), []string{"warn"})
+	assert.Equal(t, records.ByAttr("n", 2).Messages(), []string{"info"})
+	assert.Equal(t, records.ByAttr("err", ErrDuplicateMsgCtx).Messages(), []string{"error"})
+
+	t.Logf("Test_LogFuncs OK")
+}
@@ -0,0 +1,220 @@
+package goutils
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// CapturedRecord - a record of CaptureHandler, the keys of Attrs are flattened like "group.key"
+type CapturedRecord struct {
+	Time    time.Time
+	Level   slog.Level
+	Message string
+	Attrs   map[string]slog.Value
+}
+
+// Attr - the value of key, like "player.id"
+func (cr *CapturedRecord) Attr(key string) (slog.Value, bool) {
+	v, isok := cr.Attrs[key]
+
+	return v, isok
+}
+
+// CapturedRecords - records of CaptureHandler, in logging order
+type CapturedRecords []*CapturedRecord
+
+// Filter - the records funcFilter returns true
+func (lst CapturedRecords) Filter(funcFilter func(cr *CapturedRecord) bool) CapturedRecords {
+	ret := CapturedRecords{}
+	for _, cr := range lst {
+		if funcFilter(cr) {
+			ret = append(ret, cr)
+		}
+	}
+
+	return ret
+}
+
+// ByLevel - the records of lv
+func (lst CapturedRecords) ByLevel(lv slog.Level) CapturedRecords {
+	return lst.Filter(func(cr *CapturedRecord) bool {
+		return cr.Level == lv
+	})
+}
+
+// ByMessage - the records of msg
+func (lst CapturedRecords) ByMessage(msg string) CapturedRecords {
+	return lst.Filter(func(cr *CapturedRecord) bool {
+		return cr.Message == msg
+	})
+}
+
+// ByAttr - the records with the attr key = value, value is compared with slog.Value.Equal
+func (lst CapturedRecords) ByAttr(key string, value interface{}) CapturedRecords {
+	av := slog.AnyValue(value)
+
+	return lst.Filter(func(cr *CapturedRecord) bool {
+		v, isok := cr.Attrs[key]
+
+		return isok && v.Equal(av)
+	})
+}
+
+// HasAttr - the records with the attr key
+func (lst CapturedRecords) HasAttr(key string) CapturedRecords {
+	return lst.Filter(func(cr *CapturedRecord) bool {
+		_, isok := cr.Attrs[key]
+
+		return isok
+	})
+}
+
+// Messages - the messages of the records
+func (lst CapturedRecords) Messages() []string {
+	msgs := make([]string, 0, len(lst))
+	for _, cr := range lst {
+		msgs = append(msgs, cr.Message)
+	}
+
+	return msgs
+}
+
+type captureState struct {
+	lock    sync.Mutex
+	records CapturedRecords
+}
+
+// CaptureHandler - records every record in memory, for tests
+type CaptureHandler struct {
+	level  slog.Leveler
+	state  *captureState
+	attrs  []slog.Attr
+	prefix string
+}
+
+// NewCaptureHandler - new CaptureHandler, level is debug if it is nil
+func NewCaptureHandler(level slog.Leveler) *CaptureHandler {
+	if level == nil {
+		level = slog.LevelDebug
+	}
+
+	return &CaptureHandler{
+		level: level,
+		state: &captureState{},
+	}
+}
+
+func (h *CaptureHandler) Enabled(ctx context.Context, lv slog.Level) bool {
+	return lv >= h.level.Level()
+}
+
+func (h *CaptureHandler) Handle(ctx context.Context, r slog.Record) error {
+	cr := &CapturedRecord{
+		Time:    r.Time,
+		Level:   r.Level,
+		Message: r.Message,
+		Attrs:   make(map[string]slog.Value),
+	}
+
+	for _, a := range h.attrs {
+		addCapturedAttr(cr.Attrs, "", a)
+	}
+
+	r.Attrs(func(a slog.Attr) bool {
+		addCapturedAttr(cr.Attrs, h.prefix, a)
+
+		return true
+	})
+
+	h.state.lock.Lock()
+	h.state.records = append(h.state.records, cr)
+	h.state.lock.Unlock()
+
+	return nil
+}
+
+// addCapturedAttr - the groups are flattened
+func addCapturedAttr(attrs map[string]slog.Value, prefix string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+
+		for _, ga := range a.Value.Group() {
+			addCapturedAttr(attrs, prefix, ga)
+		}
+
+		return
+	}
+
+	if a.Key == "" {
+		return
+	}
+
+	attrs[prefix+a.Key] = a.Value
+}
+
+func (h *CaptureHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	nh := *h
+	nh.attrs = append([]slog.Attr{}, h.attrs...)
+
+	for _, a := range attrs {
+		if h.prefix != "" {
+			a = slog.Attr{Key: h.prefix[:len(h.prefix)-1], Value: slog.GroupValue(a)}
+		}
+
+		nh.attrs = append(nh.attrs, a)
+	}
+
+	return &nh
+}
+
+func (h *CaptureHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+
+	nh := *h
+	nh.prefix = h.prefix + name + "."
+
+	return &nh
+}
+
+// Records - all records, in logging order
+func (h *CaptureHandler) Records() CapturedRecords {
+	h.state.lock.Lock()
+	defer h.state.lock.Unlock()
+
+	return append(CapturedRecords{}, h.state.records...)
+}
+
+// Reset - remove all records
+func (h *CaptureHandler) Reset() {
+	h.state.lock.Lock()
+	h.state.records = nil
+	h.state.lock.Unlock()
+}
+
+// CaptureCleaner - the Cleanup of testing.TB, so goutils does not import testing
+type CaptureCleaner interface {
+	Cleanup(f func())
+}
+
+// CaptureLogs - set slog.Default with a CaptureHandler of all levels, the previous one is restored when t is finished,
+// t is a testing.TB. The tests using it must not run in parallel.
+func CaptureLogs(t CaptureCleaner) *CaptureHandler {
+	h := NewCaptureHandler(nil)
+
+	old := slog.Default()
+	slog.SetDefault(slog.New(h))
+
+	t.Cleanup(func() {
+		slog.SetDefault(old)
+	})
+
+	return h
+}
@@ -0,0 +1,87 @@
+package goutils
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_CaptureHandler(t *testing.T) {
+	h := NewCaptureHandler(slog.LevelInfo)
+	logger := slog.New(h)
+
+	logger.Debug("debug")
+	logger.With("app", "slots").WithGroup("player").With("id", 1).Info("login",
+		slog.Group("auth", slog.String("type", "token")))
+	logger.Error("error", Err(ErrNoMsgName), slog.Int("n", 2))
+
+	records := h.Records()
+	assert.Equal(t, records.Messages(), []string{"login", "error"})
+	assert.Equal(t, records.ByLevel(slog.LevelError).Messages(), []string{"error"})
+	assert.Equal(t, records.ByMessage("login")[0].Attrs, map[string]slog.Value{
+		"app":              slog.StringValue("slots"),
+		"player.id":        slog.Int64Value(1),
+		"player.auth.type": slog.StringValue("token"),
+	})
+	assert.Equal(t, records.ByAttr("player.id", 1).Messages(), []string{"login"})
+	assert.Equal(t, records.ByAttr("err", ErrNoMsgName).Messages(), []string{"error"})
+	assert.Equal(t, len(records.ByAttr("n", 3)), 0)
+	assert.Equal(t, records.HasAttr("n").Messages(), []string{"error"})
+
+	v, isok := records[1].Attr("n")
+	assert.True(t, isok)
+	assert.Equal(t, v.Int64(), int64(2))
+
+	h.Reset()
+	assert.Equal(t, len(h.Records()), 0)
+
+	t.Logf("Test_CaptureHandler OK")
+}
+
+func Test_CaptureLogs(t *testing.T) {
+	old := slog.Default()
+
+	t.Run("capture", func(t *testing.T) {
+		h := CaptureLogs(t)
+
+		arr, err := GetJsonIntArr([]byte(`{"arr":[1,"a",3]}`), "arr")
+		assert.NoError(t, err)
+		assert.Equal(t, arr, []int{1, 3})
+
+		records := h.Records().ByLevel(slog.LevelError)
+		assert.Equal(t, records.Messages(), []string{"GetJsonIntArr:ArrayEach:func:GetJsonArrayEachInt"})
+		assert.Equal(t, len(records.HasAttr("offset")), 1)
+
+		assert.True(t, slog.Default().Enabled(context.Background(), slog.LevelDebug))
+	})
+
+	assert.Equal(t, slog.Default(), old)
+
+	t.Logf("Test_CaptureLogs OK")
+}
+
+func Test_CaptureLogsJsonArr3(t *testing.T) {
+	h := CaptureLogs(t)
+
+	arr, err := GetJsonIntArr3([]byte(`{"a":[[[1,"x",3]]]}`), "a")
+	assert.NoError(t, err)
+	assert.Equal(t, arr, [][][]int{{{1, 3}}})
+
+	records := h.Records().ByLevel(slog.LevelError)
+	assert.Equal(t, records.Messages(), []string{"GetJsonIntArr3:ArrayEach:func3:GetJsonArrayEachInt"})
+	assert.Equal(t, len(records.HasAttr("offset")), 1)
+
+	h.Reset()
+
+	arr64, err := GetJsonInt64Arr3([]byte(`{"a":[[[1,"x",3]]]}`), "a")
+	assert.NoError(t, err)
+	assert.Equal(t, arr64, [][][]int64{{{1, 3}}})
+
+	records = h.Records().ByLevel(slog.LevelError)
+	assert.Equal(t, records.Messages(), []string{"GetJsonInt64Arr3:ArrayEach:func3:GetJsonArrayEachInt"})
+	assert.Equal(t, len(records.HasAttr("offset")), 1)
+
+	t.Logf("Test_CaptureLogsJsonArr3 OK")
+}
@@ -0,0 +1,440 @@
+package goutils
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"gopkg.in/natefinch/lumberjack.v2"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// LoggerFormatJSON - slog.JSONHandler
+	LoggerFormatJSON = "json"
+	// LoggerFormatText - slog.TextHandler
+	LoggerFormatText = "text"
+	// LoggerFormatConsole - human-readable lines, like "2006-01-02 15:04:05.000 INFO message key=value"
+	LoggerFormatConsole = "console"
+
+	// LoggerOutputStdout - os.Stdout
+	LoggerOutputStdout = "stdout"
+	// LoggerOutputStderr - os.Stderr
+	LoggerOutputStderr = "stderr"
+	// LoggerOutputFile - a file rotated by lumberjack
+	LoggerOutputFile = "file"
+)
+
+// loggerConsoleTimeFormat - default time format of LoggerFormatConsole
+const loggerConsoleTimeFormat = "2006-01-02 15:04:05.000"
+
+// LoggerOutput - an output target of LoggerConfig
+type LoggerOutput struct {
+	// Type - stdout, stderr or file
+	Type string `json:"type" yaml:"type"`
+	// Filename - the log file of file
+	Filename string `json:"filename,omitempty" yaml:"filename,omitempty"`
+	// MaxSize - megabytes of a log file before it is rotated, 0 is 100
+	MaxSize int `json:"maxSize,omitempty" yaml:"maxSize,omitempty"`
+	// MaxBackups - number of the old log files, 0 is all
+	MaxBackups int `json:"maxBackups,omitempty" yaml:"maxBackups,omitempty"`
+	// MaxAge - days to keep the old log files, 0 is forever
+	MaxAge int `json:"maxAge,omitempty" yaml:"maxAge,omitempty"`
+	// Compress - gzip the old log files
+	Compress bool `json:"compress,omitempty" yaml:"compress,omitempty"`
+	// LocalTime - use the local time in the names of the old log files, default is UTC
+	LocalTime bool `json:"localTime,omitempty" yaml:"localTime,omitempty"`
+	// Level - the fixed min level of this output, like debug for a debug file or error for an error-only file,
+	// it is not changed by the LevelController. Default is empty, the output follows the LevelController.
+	Level string `json:"level,omitempty" yaml:"level,omitempty"`
+	// Format - json, text or console, default is LoggerConfig.Format
+	Format string `json:"format,omitempty" yaml:"format,omitempty"`
+}
+
+// LoggerConfig - the config of NewLogger, it can be loaded by LoadLoggerConfig
+type LoggerConfig struct {
+	// Level - debug, info, warn or error, default is info
+	Level string `json:"level,omitempty" yaml:"level,omitempty"`
+	// Format - json, text or console, default is json
+	Format string `json:"format,omitempty" yaml:"format,omitempty"`
+	// Outputs - default is stderr, every output has its own level and format
+	Outputs []*LoggerOutput `json:"outputs,omitempty" yaml:"outputs,omitempty"`
+	// AddSource - add the source file and line
+	AddSource bool `json:"addSource,omitempty" yaml:"addSource,omitempty"`
+	// TimeFormat - layout of time.Format, like time.RFC3339
+	TimeFormat string `json:"timeFormat,omitempty" yaml:"timeFormat,omitempty"`
+	// Attrs - static attrs of every record, like app and version
+	Attrs map[string]string `json:"attrs,omitempty" yaml:"attrs,omitempty"`
+	// Levels - level overrides of the named loggers, like stats: debug, see LevelController
+	Levels map[string]string `json:"levels,omitempty" yaml:"levels,omitempty"`
+	// Redact - redact the sensitive values, nil is disabled, see RedactHandler
+	Redact *RedactOptions `json:"redact,omitempty" yaml:"redact,omitempty"`
+}
+
+// LoadLoggerConfig - load a LoggerConfig from a yaml or json file
+func LoadLoggerConfig(fn string) (*LoggerConfig, error) {
+	data, err := os.ReadFile(fn)
+	if err != nil {
+		Error("LoadLoggerConfig:ReadFile",
+			slog.String("fn", fn),
+			Err(err))
+
+		return nil, err
+	}
+
+	cfg := &LoggerConfig{}
+
+	if strings.ToLower(path.Ext(fn)) == ".json" {
+		err = json.Unmarshal(data, cfg)
+	} else {
+		err = yaml.Unmarshal(data, cfg)
+	}
+
+	if err != nil {
+		Error("LoadLoggerConfig:Unmarshal",
+			slog.String("fn", fn),
+			Err(err))
+
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// parseLoggerLevel - "" is info, it returns ErrInvalidLoggerLevel for an unknown level
+func parseLoggerLevel(str string) (slog.Level, error) {
+	if str == "" {
+		return slog.LevelInfo, nil
+	}
+
+	var lv slog.Level
+
+	err := lv.UnmarshalText([]byte(str))
+	if err != nil {
+		return slog.LevelInfo, ErrInvalidLoggerLevel
+	}
+
+	return lv, nil
+}
+
+// newLoggerWriter - the writer of an output
+func newLoggerWriter(output *LoggerOutput) (io.Writer, error) {
+	switch output.Type {
+	case LoggerOutputStdout:
+		return os.Stdout, nil
+	case LoggerOutputStderr, "":
+		return os.Stderr, nil
+	case LoggerOutputFile:
+		if output.Filename == "" {
+			return nil, ErrInvalidLoggerOutput
+		}
+
+		return &lumberjack.Logger{
+			Filename:   output.Filename,
+			MaxSize:    output.MaxSize,
+			MaxBackups: output.MaxBackups,
+			MaxAge:     output.MaxAge,
+			Compress:   output.Compress,
+			LocalTime:  output.LocalTime,
+		}, nil
+	}
+
+	return nil, ErrInvalidLoggerOutput
+}
+
+// newLoggerHandler - the handler of format
+func newLoggerHandler(w io.Writer, format string, timeFormat string, opts *slog.HandlerOptions) (slog.Handler, error) {
+	switch format {
+	case LoggerFormatJSON, "":
+		return slog.NewJSONHandler(w, withLoggerTimeFormat(opts, timeFormat)), nil
+	case LoggerFormatText:
+		return slog.NewTextHandler(w, withLoggerTimeFormat(opts, timeFormat)), nil
+	case LoggerFormatConsole:
+		if timeFormat == "" {
+			timeFormat = loggerConsoleTimeFormat
+		}
+
+		return newConsoleHandler(w, timeFormat, opts), nil
+	}
+
+	return nil, ErrInvalidLoggerFormat
+}
+
+// withLoggerTimeFormat - formats the time of records with timeFormat, opts is not changed
+func withLoggerTimeFormat(opts *slog.HandlerOptions, timeFormat string) *slog.HandlerOptions {
+	if timeFormat == "" {
+		return opts
+	}
+
+	nopts := *opts
+	replace := opts.ReplaceAttr
+
+	nopts.ReplaceAttr = func(groups []string, a slog.Attr) slog.Attr {
+		if len(groups) == 0 && a.Key == slog.TimeKey && a.Value.Kind() == slog.KindTime {
+			a = slog.String(slog.TimeKey, a.Value.Time().Format(timeFormat))
+		}
+
+		if replace != nil {
+			return replace(groups, a)
+		}
+
+		return a
+	}
+
+	return &nopts
+}
+
+// NewLogger - new slog.Logger with cfg, the attrs of WithLogAttrs and WithLogTrace in ctx are added to every record
+func NewLogger(cfg *LoggerConfig) (*slog.Logger, error) {
+	logger, _, err := NewLoggerWithController(cfg)
+
+	return logger, err
+}
+
+// NewLoggerWithController - new slog.Logger with cfg, and the LevelController to change its levels at runtime
+func NewLoggerWithController(cfg *LoggerConfig) (*slog.Logger, *LevelController, error) {
+	lv, err := parseLoggerLevel(cfg.Level)
+	if err != nil {
+		Error("NewLoggerWithController:parseLoggerLevel",
+			slog.String("level", cfg.Level),
+			Err(err))
+
+		return nil, nil, err
+	}
+
+	lc := NewLevelController(lv)
+
+	for name, strLevel := range cfg.Levels {
+		lv, err := parseLoggerLevel(strLevel)
+		if err != nil {
+			Error("NewLoggerWithController:parseLoggerLevel",
+				slog.String("name", name),
+				slog.String("level", strLevel),
+				Err(err))
+
+			return nil, nil, err
+		}
+
+		lc.SetNameLevel(name, lv)
+	}
+
+	outputs := cfg.Outputs
+	if len(outputs) == 0 {
+		outputs = []*LoggerOutput{{Type: LoggerOutputStderr}}
+	}
+
+	handlers := []slog.Handler{}
+	for _, output := range outputs {
+		h, err := newLoggerOutputHandler(cfg, output, lc)
+		if err != nil {
+			Error("NewLoggerWithController:newLoggerOutputHandler",
+				slog.String("type", output.Type),
+				slog.String("filename", output.Filename),
+				Err(err))
+
+			return nil, nil, err
+		}
+
+		handlers = append(handlers, h)
+	}
+
+	handler := handlers[0]
+	if len(handlers) > 1 {
+		handler = NewMultiHandler(handlers...)
+	}
+
+	if cfg.Redact != nil {
+		rh, err := NewRedactHandler(handler, cfg.Redact)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		handler = rh
+	}
+
+	if len(cfg.Attrs) > 0 {
+		handler = handler.WithAttrs(loggerConfigAttrs(cfg.Attrs))
+	}
+
+	return slog.New(&levelHandler{handler: NewContextHandler(handler), lc: lc}), lc, nil
+}
+
+// newLoggerOutputHandler - the level and the format of output override the ones of cfg
+func newLoggerOutputHandler(cfg *LoggerConfig, output *LoggerOutput, lc *LevelController) (slog.Handler, error) {
+	opts := &slog.HandlerOptions{
+		Level:     lc.minLevel,
+		AddSource: cfg.AddSource,
+	}
+
+	isFixedLevel := output.Level != ""
+	if isFixedLevel {
+		lv, err := parseLoggerLevel(output.Level)
+		if err != nil {
+			return nil, err
+		}
+
+		opts.Level = lv
+		lc.addOutputLevel(lv)
+	}
+
+	format := cfg.Format
+	if output.Format != "" {
+		format = output.Format
+	}
+
+	w, err := newLoggerWriter(output)
+	if err != nil {
+		return nil, err
+	}
+
+	h, err := newLoggerHandler(w, format, cfg.TimeFormat, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if isFixedLevel {
+		return h, nil
+	}
+
+	return &levelOutputHandler{handler: h}, nil
+}
+
+// loggerConfigAttrs - sorted by key
+func loggerConfigAttrs(mapAttrs map[string]string) []slog.Attr {
+	keys := make([]string, 0, len(mapAttrs))
+	for k := range mapAttrs {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	attrs := make([]slog.Attr, 0, len(keys))
+	for _, k := range keys {
+		attrs = append(attrs, slog.String(k, mapAttrs[k]))
+	}
+
+	return attrs
+}
+
+// InitLoggerWithConfig - set slog.Default with NewLoggerWithController(cfg), its LevelController is GetLevelController()
+func InitLoggerWithConfig(cfg *LoggerConfig) error {
+	logger, lc, err := NewLoggerWithController(cfg)
+	if err != nil {
+		return err
+	}
+
+	gLevelController.Store(lc)
+	slog.SetDefault(logger)
+
+	return nil
+}
+
+// consoleHandler - "time LEVEL message key=value ...", the attrs are formatted by a slog.TextHandler
+type consoleHandler struct {
+	w          io.Writer
+	timeFormat string
+	level      slog.Leveler
+	lock       *sync.Mutex
+	buf        *bytes.Buffer
+	text       slog.Handler
+}
+
+func newConsoleHandler(w io.Writer, timeFormat string, opts *slog.HandlerOptions) *consoleHandler {
+	buf := &bytes.Buffer{}
+	replace := opts.ReplaceAttr
+
+	return &consoleHandler{
+		w:          w,
+		timeFormat: timeFormat,
+		level:      opts.Level,
+		lock:       &sync.Mutex{},
+		buf:        buf,
+		text: slog.NewTextHandler(buf, &slog.HandlerOptions{
+			Level:     slog.LevelDebug - 100,
+			AddSource: opts.AddSource,
+			ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+				if len(groups) == 0 && (a.Key == slog.TimeKey || a.Key == slog.LevelKey || a.Key == slog.MessageKey) {
+					return slog.Attr{}
+				}
+
+				if replace != nil {
+					return replace(groups, a)
+				}
+
+				return a
+			},
+		}),
+	}
+}
+
+func (h *consoleHandler) Enabled(ctx context.Context, lv slog.Level) bool {
+	minLevel := slog.LevelInfo
+	if h.level != nil {
+		minLevel = h.level.Level()
+	}
+
+	return lv >= minLevel
+}
+
+func (h *consoleHandler) Handle(ctx context.Context, r slog.Record) error {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	h.buf.Reset()
+
+	err := h.text.Handle(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	line := &bytes.Buffer{}
+	if !r.Time.IsZero() {
+		line.WriteString(r.Time.Format(h.timeFormat))
+		line.WriteByte(' ')
+	}
+
+	line.WriteString(padLevel(r.Level.String()))
+	line.WriteByte(' ')
+	line.WriteString(r.Message)
+
+	if h.buf.Len() > 1 {
+		line.WriteByte(' ')
+		line.Write(h.buf.Bytes())
+	} else {
+		line.WriteByte('\n')
+	}
+
+	_, err = h.w.Write(line.Bytes())
+
+	return err
+}
+
+func (h *consoleHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	nh := *h
+	nh.text = h.text.WithAttrs(attrs)
+
+	return &nh
+}
+
+func (h *consoleHandler) WithGroup(name string) slog.Handler {
+	nh := *h
+	nh.text = h.text.WithGroup(name)
+
+	return &nh
+}
+
+// padLevel - "INFO" -> "INFO "
+func padLevel(str string) string {
+	for len(str) < 5 {
+		str += " "
+	}
+
+	return str
+}
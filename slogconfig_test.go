@@ -0,0 +1,126 @@
+package goutils
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_LoadLoggerConfig(t *testing.T) {
+	dir := t.TempDir()
+
+	fnYaml := path.Join(dir, "logger.yaml")
+	os.WriteFile(fnYaml, []byte(`
+level: debug
+format: console
+addSource: true
+timeFormat: "15:04:05"
+outputs:
+  - type: stdout
+  - type: file
+    filename: logs/app.log
+    maxSize: 10
+    maxBackups: 5
+    maxAge: 7
+    compress: true
+attrs:
+  app: slots
+  version: v1.0.1
+`), 0644)
+
+	cfg, err := LoadLoggerConfig(fnYaml)
+	assert.NoError(t, err)
+	assert.Equal(t, cfg.Level, "debug")
+	assert.Equal(t, cfg.Format, LoggerFormatConsole)
+	assert.Equal(t, cfg.AddSource, true)
+	assert.Equal(t, cfg.TimeFormat, "15:04:05")
+	assert.Equal(t, len(cfg.Outputs), 2)
+	assert.Equal(t, cfg.Outputs[0].Type, LoggerOutputStdout)
+	assert.Equal(t, *cfg.Outputs[1], LoggerOutput{
+		Type:       LoggerOutputFile,
+		Filename:   "logs/app.log",
+		MaxSize:    10,
+		MaxBackups: 5,
+		MaxAge:     7,
+		Compress:   true,
+	})
+	assert.Equal(t, cfg.Attrs, map[string]string{"app": "slots", "version": "v1.0.1"})
+
+	fnJSON := path.Join(dir, "logger.json")
+	os.WriteFile(fnJSON, []byte(`{"level":"warn","format":"text","outputs":[{"type":"stderr"}]}`), 0644)
+
+	cfg, err = LoadLoggerConfig(fnJSON)
+	assert.NoError(t, err)
+	assert.Equal(t, cfg.Level, "warn")
+	assert.Equal(t, cfg.Format, LoggerFormatText)
+
+	_, err = LoadLoggerConfig(path.Join(dir, "no.yaml"))
+	assert.Error(t, err)
+
+	t.Logf("Test_LoadLoggerConfig OK")
+}
+
+func Test_NewLogger(t *testing.T) {
+	dir := t.TempDir()
+	fn := path.Join(dir, "app.log")
+
+	logger, err := NewLogger(&LoggerConfig{
+		Level:      "warn",
+		Format:     LoggerFormatText,
+		TimeFormat: time.DateOnly,
+		Outputs:    []*LoggerOutput{{Type: LoggerOutputFile, Filename: fn}},
+		Attrs:      map[string]string{"version": "v1.0.1", "app": "slots"},
+	})
+	assert.NoError(t, err)
+
+	logger.Info("info")
+	logger.Warn("warn", slog.Int("a", 1))
+
+	data, err := os.ReadFile(fn)
+	assert.NoError(t, err)
+	assert.Equal(t, string(data), "time="+time.Now().Format(time.DateOnly)+" level=WARN msg=warn app=slots version=v1.0.1 a=1\n")
+
+	_, err = NewLogger(&LoggerConfig{Level: "verbose"})
+	assert.ErrorIs(t, err, ErrInvalidLoggerLevel)
+
+	_, err = NewLogger(&LoggerConfig{Format: "xml"})
+	assert.ErrorIs(t, err, ErrInvalidLoggerFormat)
+
+	_, err = NewLogger(&LoggerConfig{Outputs: []*LoggerOutput{{Type: "kafka"}}})
+	assert.ErrorIs(t, err, ErrInvalidLoggerOutput)
+
+	_, err = NewLogger(&LoggerConfig{Outputs: []*LoggerOutput{{Type: LoggerOutputFile}}})
+	assert.ErrorIs(t, err, ErrInvalidLoggerOutput)
+
+	t.Logf("Test_NewLogger OK")
+}
+
+func Test_consoleHandler(t *testing.T) {
+	buf := &bytes.Buffer{}
+
+	lvl := &slog.LevelVar{}
+	logger := slog.New(newConsoleHandler(buf, "15:04:05", &slog.HandlerOptions{Level: lvl}))
+
+	logger.Debug("debug")
+	assert.Equal(t, buf.Len(), 0)
+
+	r := slog.NewRecord(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), slog.LevelWarn, "hello world", 0)
+	r.AddAttrs(slog.String("b", "x y"))
+
+	logger.With("a", 1).WithGroup("g").Handler().Handle(context.Background(), r)
+	assert.Equal(t, buf.String(), "03:04:05 WARN  hello world a=1 g.b=\"x y\"\n")
+
+	buf.Reset()
+
+	r = slog.NewRecord(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), slog.LevelInfo, "empty", 0)
+	logger.Handler().Handle(context.Background(), r)
+	assert.Equal(t, buf.String(), "03:04:05 INFO  empty\n")
+
+	t.Logf("Test_consoleHandler OK")
+}
@@ -0,0 +1,132 @@
+package goutils
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+)
+
+const (
+	// LogTraceIDKey - the attr key of the trace id
+	LogTraceIDKey = "traceId"
+	// LogSpanIDKey - the attr key of the span id
+	LogSpanIDKey = "spanId"
+)
+
+type logAttrsKey struct{}
+
+type logTraceKey struct{}
+
+type logTrace struct {
+	traceID string
+	spanID  string
+}
+
+// FuncLogTrace - returns the trace id and the span id in ctx, "" if there is none
+type FuncLogTrace func(ctx context.Context) (traceID string, spanID string)
+
+var gFuncLogTrace atomic.Pointer[FuncLogTrace]
+
+// SetLogTraceFunc - set the function to get the trace id and the span id, like from an OpenTelemetry span,
+// default is LogTraceFromContext, nil disables it. It can be called after logging has started.
+func SetLogTraceFunc(funcTrace FuncLogTrace) {
+	gFuncLogTrace.Store(&funcTrace)
+}
+
+// WithLogAttrs - returns a ctx with attrs, they are added to every record logged with it
+func WithLogAttrs(ctx context.Context, attrs ...slog.Attr) context.Context {
+	parent := LogAttrsFromContext(ctx)
+
+	lst := make([]slog.Attr, 0, len(parent)+len(attrs))
+	lst = append(lst, parent...)
+	lst = append(lst, attrs...)
+
+	return context.WithValue(ctx, logAttrsKey{}, lst)
+}
+
+// LogAttrsFromContext - the attrs of WithLogAttrs, nil if there is none
+func LogAttrsFromContext(ctx context.Context) []slog.Attr {
+	attrs, _ := ctx.Value(logAttrsKey{}).([]slog.Attr)
+
+	return attrs
+}
+
+// WithLogTrace - returns a ctx with the trace id and the span id
+func WithLogTrace(ctx context.Context, traceID string, spanID string) context.Context {
+	return context.WithValue(ctx, logTraceKey{}, &logTrace{
+		traceID: traceID,
+		spanID:  spanID,
+	})
+}
+
+// LogTraceFromContext - the trace id and the span id of WithLogTrace
+func LogTraceFromContext(ctx context.Context) (string, string) {
+	trace, isok := ctx.Value(logTraceKey{}).(*logTrace)
+	if !isok {
+		return "", ""
+	}
+
+	return trace.traceID, trace.spanID
+}
+
+// ContextHandler - adds the attrs of WithLogAttrs and the trace id / span id of ctx to every record
+type ContextHandler struct {
+	handler slog.Handler
+}
+
+// NewContextHandler - new ContextHandler
+func NewContextHandler(handler slog.Handler) *ContextHandler {
+	return &ContextHandler{
+		handler: handler,
+	}
+}
+
+func (h *ContextHandler) Enabled(ctx context.Context, lv slog.Level) bool {
+	return h.handler.Enabled(ctx, lv)
+}
+
+func (h *ContextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if ctx == nil {
+		return h.handler.Handle(ctx, r)
+	}
+
+	attrs := LogAttrsFromContext(ctx)
+	traceID, spanID := "", ""
+	if funcTrace := gFuncLogTrace.Load(); funcTrace != nil && *funcTrace != nil {
+		traceID, spanID = (*funcTrace)(ctx)
+	}
+
+	if len(attrs) == 0 && traceID == "" && spanID == "" {
+		return h.handler.Handle(ctx, r)
+	}
+
+	r = r.Clone()
+	r.AddAttrs(attrs...)
+
+	if traceID != "" {
+		r.AddAttrs(slog.String(LogTraceIDKey, traceID))
+	}
+
+	if spanID != "" {
+		r.AddAttrs(slog.String(LogSpanIDKey, spanID))
+	}
+
+	return h.handler.Handle(ctx, r)
+}
+
+func (h *ContextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &ContextHandler{
+		handler: h.handler.WithAttrs(attrs),
+	}
+}
+
+// WithGroup - the attrs of ctx are in the group too
+func (h *ContextHandler) WithGroup(name string) slog.Handler {
+	return &ContextHandler{
+		handler: h.handler.WithGroup(name),
+	}
+}
+
+func init() {
+	SetLogTraceFunc(LogTraceFromContext)
+}
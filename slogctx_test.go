@@ -0,0 +1,106 @@
+package goutils
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ContextHandler(t *testing.T) {
+	buf := &bytes.Buffer{}
+
+	logger := slog.New(NewContextHandler(slog.NewTextHandler(buf, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+
+			return a
+		},
+	})))
+
+	ctx := WithLogAttrs(context.Background(), slog.String("requestId", "r1"))
+	ctx1 := WithLogAttrs(ctx, slog.Int("playerId", 123))
+
+	logger.InfoContext(ctx1, "msg1", slog.Int("a", 1))
+	assert.Equal(t, buf.String(), "level=INFO msg=msg1 a=1 requestId=r1 playerId=123\n")
+	assert.Equal(t, len(LogAttrsFromContext(ctx)), 1)
+
+	buf.Reset()
+
+	ctx2 := WithLogTrace(ctx, "t1", "s1")
+	logger.With("b", 2).InfoContext(ctx2, "msg2")
+	assert.Equal(t, buf.String(), "level=INFO msg=msg2 b=2 requestId=r1 traceId=t1 spanId=s1\n")
+
+	buf.Reset()
+
+	logger.Info("msg3")
+	assert.Equal(t, buf.String(), "level=INFO msg=msg3\n")
+
+	buf.Reset()
+
+	SetLogTraceFunc(func(ctx context.Context) (string, string) {
+		return "t2", ""
+	})
+	defer SetLogTraceFunc(LogTraceFromContext)
+
+	logger.InfoContext(context.Background(), "msg4")
+	assert.Equal(t, buf.String(), "level=INFO msg=msg4 traceId=t2\n")
+
+	t.Logf("Test_ContextHandler OK")
+}
+
+func Test_InfoCtx(t *testing.T) {
+	buf := &bytes.Buffer{}
+
+	old := slog.Default()
+	defer slog.SetDefault(old)
+
+	slog.SetDefault(slog.New(NewContextHandler(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))))
+
+	ctx := WithLogAttrs(context.Background(), slog.String("requestId", "r1"))
+
+	DebugCtx(ctx, "debug")
+	InfoCtx(ctx, "info")
+	WarnCtx(ctx, "warn")
+	ErrorCtx(ctx, "error", Err(ErrNoMsgName))
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	assert.Equal(t, len(lines), 4)
+	assert.Contains(t, string(lines[0]), `"level":"DEBUG","msg":"debug","requestId":"r1"`)
+	assert.Contains(t, string(lines[3]), `"level":"ERROR","msg":"error","err":"no MsgName","requestId":"r1"`)
+
+	t.Logf("Test_InfoCtx OK")
+}
+
+func Test_SetLogTraceFuncRace(t *testing.T) {
+	logger := slog.New(NewContextHandler(slog.NewTextHandler(io.Discard, nil)))
+	defer SetLogTraceFunc(LogTraceFromContext)
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for j := 0; j < 100; j++ {
+				logger.InfoContext(context.Background(), "msg")
+			}
+		}()
+	}
+
+	for j := 0; j < 100; j++ {
+		SetLogTraceFunc(nil)
+		SetLogTraceFunc(LogTraceFromContext)
+	}
+
+	wg.Wait()
+
+	t.Logf("Test_SetLogTraceFuncRace OK")
+}
@@ -0,0 +1,127 @@
+package goutils
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync/atomic"
+)
+
+// LogErrorOpKey - the attr key of the ops of AttrError, see LogError
+const LogErrorOpKey = "op"
+
+// AttrError - an error with the operation name and the slog attrs of a failure,
+// it can be returned instead of logging the error and returning the sentinel,
+// then the caller logs it once with LogError.
+// errors.Is and errors.As see Err. It is opt-in, the existing functions still return the sentinels.
+type AttrError struct {
+	Op    string
+	Err   error
+	Attrs []slog.Attr
+
+	isLogged atomic.Bool
+}
+
+// NewAttrError - new AttrError
+func NewAttrError(op string, err error, attrs ...slog.Attr) *AttrError {
+	return &AttrError{
+		Op:    op,
+		Err:   err,
+		Attrs: attrs,
+	}
+}
+
+// Error - like "loadReels: invalid array length"
+func (err *AttrError) Error() string {
+	if err.Err == nil {
+		return err.Op
+	}
+
+	if err.Op == "" {
+		return err.Err.Error()
+	}
+
+	return err.Op + ": " + err.Err.Error()
+}
+
+// Unwrap - for errors.Is and errors.As
+func (err *AttrError) Unwrap() error {
+	return err.Err
+}
+
+// With - add attrs, returns err
+func (err *AttrError) With(attrs ...slog.Attr) *AttrError {
+	err.Attrs = append(err.Attrs, attrs...)
+
+	return err
+}
+
+// walkAttrErrors - call onAttrError with every AttrError in the tree of err, the outer ones first
+func walkAttrErrors(err error, onAttrError func(ae *AttrError)) {
+	for err != nil {
+		if ae, isok := err.(*AttrError); isok {
+			onAttrError(ae)
+		}
+
+		switch e := err.(type) {
+		case interface{ Unwrap() error }:
+			err = e.Unwrap()
+		case interface{ Unwrap() []error }:
+			for _, ce := range e.Unwrap() {
+				walkAttrErrors(ce, onAttrError)
+			}
+
+			return
+		default:
+			return
+		}
+	}
+}
+
+// ErrorAttrs - the attrs of all AttrError in err, the outer ones first,
+// the ops are joined with ':' as the attr LogErrorOpKey
+func ErrorAttrs(err error) []slog.Attr {
+	ops := []string{}
+	attrs := []slog.Attr{}
+
+	walkAttrErrors(err, func(ae *AttrError) {
+		if ae.Op != "" {
+			ops = append(ops, ae.Op)
+		}
+
+		attrs = append(attrs, ae.Attrs...)
+	})
+
+	if len(ops) > 0 {
+		attrs = append([]slog.Attr{slog.String(LogErrorOpKey, strings.Join(ops, ":"))}, attrs...)
+	}
+
+	return attrs
+}
+
+// LogError - log err at the error level with fields, ErrorAttrs(err) and Err(err).
+// The outermost AttrError in err is marked as logged, and err is skipped if it has been marked,
+// so a failure returned up the stack is logged once, and logged again only if it is wrapped by a new AttrError.
+// An AttrError is one failure, do not reuse it, a package-level AttrError must be wrapped by NewAttrError for every failure.
+func LogError(message string, err error, fields ...slog.Attr) {
+	LogErrorCtx(context.Background(), message, err, fields...)
+}
+
+// LogErrorCtx - LogError with ctx
+func LogErrorCtx(ctx context.Context, message string, err error, fields ...slog.Attr) {
+	if err == nil {
+		return
+	}
+
+	var ae *AttrError
+	if errors.As(err, &ae) && ae.isLogged.Swap(true) {
+		return
+	}
+
+	attrs := append([]slog.Attr{}, fields...)
+	attrs = append(attrs, ErrorAttrs(err)...)
+	attrs = append(attrs, Err(err))
+
+	slog.LogAttrs(ctx, slog.LevelError, message, attrs...)
+}
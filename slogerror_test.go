@@ -0,0 +1,100 @@
+package goutils
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func loadTestReels(arr []int32) ([][]int, error) {
+	if len(arr) != 4 {
+		return nil, NewAttrError("loadTestReels", ErrInvalidArrayLength,
+			slog.Int("len", len(arr)),
+			slog.Int("x", 2),
+			slog.Int("y", 2))
+	}
+
+	return Int32ArrToIntArr2(arr, 2, 2)
+}
+
+func Test_AttrError(t *testing.T) {
+	_, err := loadTestReels([]int32{1, 2, 3})
+	assert.ErrorIs(t, err, ErrInvalidArrayLength)
+	assert.Equal(t, err.Error(), "loadTestReels: invalid array length")
+
+	var ae *AttrError
+	assert.True(t, errors.As(err, &ae))
+	assert.Equal(t, ae.Op, "loadTestReels")
+	assert.Equal(t, ae.Attrs, []slog.Attr{slog.Int("len", 3), slog.Int("x", 2), slog.Int("y", 2)})
+
+	err1 := NewAttrError("loadReels", fmt.Errorf("parse: %w", err), slog.String("fn", "reels.xlsx"))
+	assert.ErrorIs(t, err1, ErrInvalidArrayLength)
+	assert.Equal(t, err1.Error(), "loadReels: parse: loadTestReels: invalid array length")
+	assert.Equal(t, ErrorAttrs(err1), []slog.Attr{
+		slog.String(LogErrorOpKey, "loadReels:loadTestReels"),
+		slog.String("fn", "reels.xlsx"),
+		slog.Int("len", 3),
+		slog.Int("x", 2),
+		slog.Int("y", 2),
+	})
+
+	err2 := errors.Join(ErrNoMsgName, NewAttrError("", ErrNoMsgCtx).With(slog.Int("n", 1)))
+	assert.Equal(t, ErrorAttrs(err2), []slog.Attr{slog.Int("n", 1)})
+	assert.Equal(t, len(ErrorAttrs(ErrNoMsgName)), 0)
+
+	// the existing functions still return the sentinels
+	_, err = Int32ArrToIntArr2([]int32{1, 2, 3}, 2, 2)
+	assert.True(t, err == ErrInvalidArrayLength)
+
+	_, _, err = GetJsonInt([]byte(`{"a":[1]}`), "a")
+	assert.True(t, err == ErrInvalidJsonInt)
+
+	t.Logf("Test_AttrError OK")
+}
+
+func Test_LogError(t *testing.T) {
+	h := CaptureLogs(t)
+
+	_, err := loadTestReels([]int32{1, 2, 3})
+	assert.ErrorIs(t, err, ErrInvalidArrayLength)
+	assert.Equal(t, len(h.Records()), 0)
+
+	err1 := fmt.Errorf("loadReels: %w", err)
+
+	LogError("loadReels", err1, slog.String("game", "slots"))
+	LogError("loadReels", err1)
+	LogError("loadReels", err)
+	LogError("loadReels", nil)
+
+	records := h.Records()
+	assert.Equal(t, records.Messages(), []string{"loadReels"})
+	assert.Equal(t, len(records.ByAttr("game", "slots")), 1)
+	assert.Equal(t, len(records.ByAttr(LogErrorOpKey, "loadTestReels")), 1)
+	assert.Equal(t, len(records.ByAttr("x", 2)), 1)
+	assert.Equal(t, len(records.ByAttr("err", err1)), 1)
+
+	// wrapped again with more context, it is logged again
+	LogError("init", NewAttrError("init", err1, slog.Int("gameid", 1)))
+	assert.Equal(t, h.Records().ByAttr(LogErrorOpKey, "init:loadTestReels").Messages(), []string{"init"})
+
+	// a new failure is logged
+	_, err = loadTestReels(nil)
+	LogError("loadReels", err)
+	assert.Equal(t, len(h.Records().ByMessage("loadReels")), 2)
+
+	// a package-level AttrError wrapped for every failure
+	errPkg := NewAttrError("pkg", ErrNoMsgCtx)
+	LogError("pkg", NewAttrError("call", errPkg))
+	LogError("pkg", NewAttrError("call", errPkg))
+	assert.Equal(t, len(h.Records().ByMessage("pkg")), 2)
+
+	// the errors without AttrError are always logged
+	LogError("load", ErrNoMsgName)
+	LogError("load", ErrNoMsgName)
+	assert.Equal(t, len(h.Records().ByMessage("load")), 2)
+
+	t.Logf("Test_LogError OK")
+}
@@ -0,0 +1,402 @@
+package goutils
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+// LoggerNameKey - the attr key of the logger name, see LevelController.Logger
+const LoggerNameKey = "logger"
+
+// LevelController - the level of a logger and the level overrides of its named loggers, they can be changed at runtime
+type LevelController struct {
+	level     *slog.LevelVar
+	minLevel  *slog.LevelVar
+	names     map[string]slog.Level
+	lastLevel slog.Level
+	isDebug   bool
+	lock      sync.RWMutex
+
+	// outputLevel - the min level of the outputs with a fixed level, see LoggerOutput.Level
+	outputLevel    slog.LevelVar
+	hasOutputLevel atomic.Bool
+}
+
+// NewLevelController - new LevelController
+func NewLevelController(lv slog.Level) *LevelController {
+	lc := &LevelController{
+		level:    &slog.LevelVar{},
+		minLevel: &slog.LevelVar{},
+		names:    make(map[string]slog.Level),
+	}
+
+	lc.level.Set(lv)
+	lc.minLevel.Set(lv)
+
+	return lc
+}
+
+// Level - the level of the loggers without an override
+func (lc *LevelController) Level() slog.Level {
+	return lc.level.Level()
+}
+
+// SetLevel - set the level of the loggers without an override, it cancels ToggleDebug
+func (lc *LevelController) SetLevel(lv slog.Level) {
+	lc.lock.Lock()
+	lc.level.Set(lv)
+	lc.isDebug = false
+	lc.updateMinLevel()
+	lc.lock.Unlock()
+}
+
+// ToggleDebug - set the level to debug, or back to the level before it, returns true if it is debug now
+func (lc *LevelController) ToggleDebug() bool {
+	lc.lock.Lock()
+	defer lc.lock.Unlock()
+
+	if lc.isDebug {
+		lc.level.Set(lc.lastLevel)
+		lc.isDebug = false
+	} else {
+		lc.lastLevel = lc.level.Level()
+		lc.level.Set(slog.LevelDebug)
+		lc.isDebug = true
+	}
+
+	lc.updateMinLevel()
+
+	return lc.isDebug
+}
+
+// NameLevel - the level of a named logger
+func (lc *LevelController) NameLevel(name string) slog.Level {
+	lc.lock.RLock()
+	defer lc.lock.RUnlock()
+
+	lv, isok := lc.names[name]
+	if isok {
+		return lv
+	}
+
+	return lc.level.Level()
+}
+
+// SetNameLevel - override the level of a named logger
+func (lc *LevelController) SetNameLevel(name string, lv slog.Level) {
+	lc.lock.Lock()
+	lc.names[name] = lv
+	lc.updateMinLevel()
+	lc.lock.Unlock()
+}
+
+// ResetNameLevel - remove the override of a named logger
+func (lc *LevelController) ResetNameLevel(name string) {
+	lc.lock.Lock()
+	delete(lc.names, name)
+	lc.updateMinLevel()
+	lc.lock.Unlock()
+}
+
+// updateMinLevel - lc.lock must be locked
+func (lc *LevelController) updateMinLevel() {
+	minLevel := lc.level.Level()
+	for _, lv := range lc.names {
+		if lv < minLevel {
+			minLevel = lv
+		}
+	}
+
+	lc.minLevel.Set(minLevel)
+}
+
+// addOutputLevel - an output with the fixed level lv, it gets the records of lv even if the levels of lc are higher
+func (lc *LevelController) addOutputLevel(lv slog.Level) {
+	lc.lock.Lock()
+	if !lc.hasOutputLevel.Load() || lv < lc.outputLevel.Level() {
+		lc.outputLevel.Set(lv)
+	}
+
+	lc.hasOutputLevel.Store(true)
+	lc.lock.Unlock()
+}
+
+// isOutputLevel - returns true if an output with a fixed level gets the records of lv
+func (lc *LevelController) isOutputLevel(lv slog.Level) bool {
+	return lc.hasOutputLevel.Load() && lv >= lc.outputLevel.Level()
+}
+
+// Levels - the level and the overrides, like "INFO,stats=DEBUG", the overrides are sorted by name
+func (lc *LevelController) Levels() string {
+	lc.lock.RLock()
+	defer lc.lock.RUnlock()
+
+	names := make([]string, 0, len(lc.names))
+	for k := range lc.names {
+		names = append(names, k)
+	}
+
+	sort.Strings(names)
+
+	lst := []string{lc.level.Level().String()}
+	for _, name := range names {
+		lst = append(lst, name+"="+lc.names[name].String())
+	}
+
+	return strings.Join(lst, ",")
+}
+
+// SetLevels - parse a string like "info,stats=debug,net=warn", an item without a name is the level.
+// Nothing is changed if it returns an error.
+func (lc *LevelController) SetLevels(str string) error {
+	level := lc.Level()
+	names := make(map[string]slog.Level)
+
+	for _, item := range strings.Split(str, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		name, strLevel, isName := strings.Cut(item, "=")
+		if !isName {
+			strLevel = name
+		}
+
+		lv, err := parseLoggerLevel(strings.TrimSpace(strLevel))
+		if err != nil || strLevel == "" {
+			Warn("LevelController.SetLevels:parseLoggerLevel",
+				slog.String("item", item),
+				Err(ErrInvalidLoggerLevel))
+
+			return ErrInvalidLoggerLevel
+		}
+
+		if isName {
+			names[strings.TrimSpace(name)] = lv
+		} else {
+			level = lv
+		}
+	}
+
+	lc.lock.Lock()
+	lc.level.Set(level)
+	lc.isDebug = false
+	for k, v := range names {
+		lc.names[k] = v
+	}
+	lc.updateMinLevel()
+	lc.lock.Unlock()
+
+	return nil
+}
+
+// Logger - a named logger of logger, its records have the attr logger=name, and its level is NameLevel(name)
+func (lc *LevelController) Logger(logger *slog.Logger, name string) *slog.Logger {
+	handler := logger.Handler()
+	if lh, isok := handler.(*levelHandler); isok {
+		handler = lh.handler
+	}
+
+	return slog.New(&levelHandler{
+		handler: handler.WithAttrs([]slog.Attr{slog.String(LoggerNameKey, name)}),
+		lc:      lc,
+		name:    name,
+	})
+}
+
+// ServeHTTP - GET returns the levels, POST or PUT sets them, the response is always the levels.
+//
+//	GET  /loglevel                               {"level":"INFO","names":{"stats":"DEBUG"}}
+//	POST /loglevel?level=debug                   set the level
+//	POST /loglevel?name=stats&level=debug        override the level of stats
+//	POST /loglevel?name=stats&level=             remove the override of stats
+//	POST /loglevel?levels=info,stats=debug       like SetLevels
+func (lc *LevelController) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost, http.MethodPut:
+		err := lc.setLevelsWithRequest(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+
+			return
+		}
+	default:
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+
+		return
+	}
+
+	json := jsoniter.ConfigCompatibleWithStandardLibrary
+
+	b, err := json.Marshal(lc.levelsJSON())
+	if err != nil {
+		Warn("LevelController.ServeHTTP:Marshal",
+			Err(err))
+
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(b)
+}
+
+func (lc *LevelController) setLevelsWithRequest(r *http.Request) error {
+	err := r.ParseForm()
+	if err != nil {
+		return err
+	}
+
+	if r.Form.Has("levels") {
+		return lc.SetLevels(r.Form.Get("levels"))
+	}
+
+	name := r.Form.Get("name")
+	strLevel := r.Form.Get("level")
+
+	if name != "" && strLevel == "" {
+		lc.ResetNameLevel(name)
+
+		return nil
+	}
+
+	lv, err := parseLoggerLevel(strLevel)
+	if err != nil || strLevel == "" {
+		return ErrInvalidLoggerLevel
+	}
+
+	if name != "" {
+		lc.SetNameLevel(name, lv)
+	} else {
+		lc.SetLevel(lv)
+	}
+
+	Info("LevelController:setLevelsWithRequest",
+		slog.String("levels", lc.Levels()))
+
+	return nil
+}
+
+type levelControllerJSON struct {
+	Level string            `json:"level"`
+	Names map[string]string `json:"names"`
+}
+
+func (lc *LevelController) levelsJSON() *levelControllerJSON {
+	lc.lock.RLock()
+	defer lc.lock.RUnlock()
+
+	ret := &levelControllerJSON{
+		Level: lc.level.Level().String(),
+		Names: make(map[string]string, len(lc.names)),
+	}
+
+	for k, v := range lc.names {
+		ret.Names[k] = v.String()
+	}
+
+	return ret
+}
+
+// levelHandler - the records are filtered by the level of name in lc, handler must be enabled for lc.minLevel.
+// The records below it are still passed to the outputs with a fixed level, they are dropped by levelOutputHandler.
+type levelHandler struct {
+	handler slog.Handler
+	lc      *LevelController
+	name    string
+}
+
+func (h *levelHandler) level() slog.Level {
+	if h.name == "" {
+		return h.lc.level.Level()
+	}
+
+	return h.lc.NameLevel(h.name)
+}
+
+func (h *levelHandler) Enabled(ctx context.Context, lv slog.Level) bool {
+	if lv < h.level() && !h.lc.isOutputLevel(lv) {
+		return false
+	}
+
+	return h.handler.Enabled(ctx, lv)
+}
+
+func (h *levelHandler) Handle(ctx context.Context, r slog.Record) error {
+	if r.Level < h.level() {
+		ctx = context.WithValue(ctx, levelBelowKey{}, true)
+	}
+
+	return h.handler.Handle(ctx, r)
+}
+
+func (h *levelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &levelHandler{
+		handler: h.handler.WithAttrs(attrs),
+		lc:      h.lc,
+		name:    h.name,
+	}
+}
+
+func (h *levelHandler) WithGroup(name string) slog.Handler {
+	return &levelHandler{
+		handler: h.handler.WithGroup(name),
+		lc:      h.lc,
+		name:    h.name,
+	}
+}
+
+// levelBelowKey - the context key of the records below the levels of LevelController
+type levelBelowKey struct{}
+
+// levelOutputHandler - an output following the levels of LevelController,
+// it drops the records passed by levelHandler only for the outputs with a fixed level
+type levelOutputHandler struct {
+	handler slog.Handler
+}
+
+func (h *levelOutputHandler) Enabled(ctx context.Context, lv slog.Level) bool {
+	return h.handler.Enabled(ctx, lv)
+}
+
+func (h *levelOutputHandler) Handle(ctx context.Context, r slog.Record) error {
+	if ctx.Value(levelBelowKey{}) != nil {
+		return nil
+	}
+
+	return h.handler.Handle(ctx, r)
+}
+
+func (h *levelOutputHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &levelOutputHandler{handler: h.handler.WithAttrs(attrs)}
+}
+
+func (h *levelOutputHandler) WithGroup(name string) slog.Handler {
+	return &levelOutputHandler{handler: h.handler.WithGroup(name)}
+}
+
+var gLevelController atomic.Pointer[LevelController]
+
+// GetLevelController - the LevelController of the logger set by InitLogger2 or InitLoggerWithConfig
+func GetLevelController() *LevelController {
+	return gLevelController.Load()
+}
+
+// NamedLogger - a named logger of slog.Default, its level can be overridden by GetLevelController().SetNameLevel
+func NamedLogger(name string) *slog.Logger {
+	return GetLevelController().Logger(slog.Default(), name)
+}
+
+func init() {
+	gLevelController.Store(NewLevelController(slog.LevelInfo))
+}
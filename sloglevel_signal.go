@@ -0,0 +1,37 @@
+//go:build !windows
+
+package goutils
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// WatchSignals - SIGUSR1 toggles the level between debug and the level before it, SIGUSR2 logs the levels.
+// It returns at once, the signals are watched until ctx is done.
+func (lc *LevelController) WatchSignals(ctx context.Context) {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, syscall.SIGUSR1, syscall.SIGUSR2)
+
+	go func() {
+		defer signal.Stop(ch)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case sig := <-ch:
+				if sig == syscall.SIGUSR1 {
+					lc.ToggleDebug()
+				}
+
+				Info("LevelController.WatchSignals",
+					slog.String("signal", sig.String()),
+					slog.String("levels", lc.Levels()))
+			}
+		}
+	}()
+}
@@ -0,0 +1,34 @@
+//go:build !windows
+
+package goutils
+
+import (
+	"context"
+	"log/slog"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_LevelControllerWatchSignals(t *testing.T) {
+	lc := NewLevelController(slog.LevelWarn)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	lc.WatchSignals(ctx)
+
+	syscall.Kill(syscall.Getpid(), syscall.SIGUSR1)
+	assert.Eventually(t, func() bool {
+		return lc.Level() == slog.LevelDebug
+	}, time.Second, time.Millisecond)
+
+	syscall.Kill(syscall.Getpid(), syscall.SIGUSR1)
+	assert.Eventually(t, func() bool {
+		return lc.Level() == slog.LevelWarn
+	}, time.Second, time.Millisecond)
+
+	t.Logf("Test_LevelControllerWatchSignals OK")
+}
@@ -0,0 +1,9 @@
+package goutils
+
+import (
+	"context"
+)
+
+// WatchSignals - there is no SIGUSR1 / SIGUSR2 on windows, it does nothing
+func (lc *LevelController) WatchSignals(ctx context.Context) {
+}
@@ -0,0 +1,122 @@
+package goutils
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_LevelController(t *testing.T) {
+	fn := path.Join(t.TempDir(), "app.log")
+
+	logger, lc, err := NewLoggerWithController(&LoggerConfig{
+		Level:   "info",
+		Format:  LoggerFormatJSON,
+		Outputs: []*LoggerOutput{{Type: LoggerOutputFile, Filename: fn}},
+		Levels:  map[string]string{"net": "warn"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, lc.Levels(), "INFO,net=WARN")
+
+	stats := lc.Logger(logger, "stats")
+	net := lc.Logger(logger.With("a", 1), "net")
+
+	logger.Debug("root-debug")
+	stats.Debug("stats-debug")
+	net.Info("net-info")
+
+	lc.SetNameLevel("stats", slog.LevelDebug)
+	stats.Debug("stats-debug-2")
+	logger.Debug("root-debug-2")
+
+	assert.True(t, lc.ToggleDebug())
+	logger.Debug("root-debug-3")
+	net.Info("net-info-2")
+	assert.False(t, lc.ToggleDebug())
+	assert.Equal(t, lc.Level(), slog.LevelInfo)
+
+	lc.ResetNameLevel("stats")
+	stats.Debug("stats-debug-4")
+
+	assert.ErrorIs(t, lc.SetLevels("warn,net=verbose"), ErrInvalidLoggerLevel)
+	assert.Equal(t, lc.Levels(), "INFO,net=WARN")
+	assert.NoError(t, lc.SetLevels("error, net=debug ,db=info"))
+	assert.Equal(t, lc.Levels(), "ERROR,db=INFO,net=DEBUG")
+	assert.Equal(t, lc.NameLevel("stats"), slog.LevelError)
+
+	net.Debug("net-debug")
+
+	data, err := os.ReadFile(fn)
+	assert.NoError(t, err)
+
+	str := string(data)
+	assert.NotContains(t, str, `"root-debug"`)
+	assert.NotContains(t, str, `"stats-debug"`)
+	assert.NotContains(t, str, `"net-info"`)
+	assert.Contains(t, str, `"msg":"stats-debug-2","logger":"stats"`)
+	assert.NotContains(t, str, "root-debug-2")
+	assert.Contains(t, str, `"msg":"root-debug-3"`)
+	assert.NotContains(t, str, "net-info-2")
+	assert.NotContains(t, str, "stats-debug-4")
+	assert.Contains(t, str, `"msg":"net-debug","a":1,"logger":"net"`)
+
+	_, _, err = NewLoggerWithController(&LoggerConfig{Levels: map[string]string{"net": "verbose"}})
+	assert.ErrorIs(t, err, ErrInvalidLoggerLevel)
+
+	t.Logf("Test_LevelController OK")
+}
+
+func Test_LevelControllerHTTP(t *testing.T) {
+	lc := NewLevelController(slog.LevelInfo)
+
+	rec := httptest.NewRecorder()
+	lc.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/loglevel", nil))
+	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.Equal(t, rec.Body.String(), `{"level":"INFO","names":{}}`)
+
+	rec = httptest.NewRecorder()
+	lc.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/loglevel?level=debug", nil))
+	assert.Equal(t, rec.Body.String(), `{"level":"DEBUG","names":{}}`)
+
+	rec = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPut, "/loglevel", strings.NewReader("name=stats&level=warn"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	lc.ServeHTTP(rec, req)
+	assert.Equal(t, rec.Body.String(), `{"level":"DEBUG","names":{"stats":"WARN"}}`)
+
+	rec = httptest.NewRecorder()
+	lc.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/loglevel?name=stats&level=", nil))
+	assert.Equal(t, rec.Body.String(), `{"level":"DEBUG","names":{}}`)
+
+	rec = httptest.NewRecorder()
+	lc.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/loglevel?levels=warn,net=error", nil))
+	assert.Equal(t, rec.Body.String(), `{"level":"WARN","names":{"net":"ERROR"}}`)
+
+	rec = httptest.NewRecorder()
+	lc.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/loglevel?level=verbose", nil))
+	assert.Equal(t, rec.Code, http.StatusBadRequest)
+
+	rec = httptest.NewRecorder()
+	lc.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/loglevel", nil))
+	assert.Equal(t, rec.Code, http.StatusMethodNotAllowed)
+
+	t.Logf("Test_LevelControllerHTTP OK")
+}
+
+func Test_InitLoggerWithConfig(t *testing.T) {
+	defer slog.SetDefault(slog.Default())
+
+	assert.NoError(t, InitLoggerWithConfig(&LoggerConfig{Level: "warn", Levels: map[string]string{"stats": "debug"}}))
+	assert.Equal(t, GetLevelController().Levels(), "WARN,stats=DEBUG")
+	assert.True(t, NamedLogger("stats").Enabled(context.Background(), slog.LevelDebug))
+	assert.False(t, slog.Default().Enabled(context.Background(), slog.LevelInfo))
+
+	t.Logf("Test_InitLoggerWithConfig OK")
+}
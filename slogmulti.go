@@ -0,0 +1,66 @@
+package goutils
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+)
+
+// MultiHandler - tees every record to all handlers, every handler checks its own level
+type MultiHandler struct {
+	handlers []slog.Handler
+}
+
+// NewMultiHandler - new MultiHandler
+func NewMultiHandler(handlers ...slog.Handler) *MultiHandler {
+	return &MultiHandler{
+		handlers: handlers,
+	}
+}
+
+// Enabled - true if any handler is enabled
+func (h *MultiHandler) Enabled(ctx context.Context, lv slog.Level) bool {
+	for _, handler := range h.handlers {
+		if handler.Enabled(ctx, lv) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Handle - the record is handled by all enabled handlers, even if some of them fail
+func (h *MultiHandler) Handle(ctx context.Context, r slog.Record) error {
+	var errs []error
+
+	for _, handler := range h.handlers {
+		if !handler.Enabled(ctx, r.Level) {
+			continue
+		}
+
+		err := handler.Handle(ctx, r.Clone())
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (h *MultiHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handlers := make([]slog.Handler, 0, len(h.handlers))
+	for _, handler := range h.handlers {
+		handlers = append(handlers, handler.WithAttrs(attrs))
+	}
+
+	return NewMultiHandler(handlers...)
+}
+
+func (h *MultiHandler) WithGroup(name string) slog.Handler {
+	handlers := make([]slog.Handler, 0, len(h.handlers))
+	for _, handler := range h.handlers {
+		handlers = append(handlers, handler.WithGroup(name))
+	}
+
+	return NewMultiHandler(handlers...)
+}
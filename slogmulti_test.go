@@ -0,0 +1,141 @@
+package goutils
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type errLogWriter struct{}
+
+func (w *errLogWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("write error")
+}
+
+func Test_MultiHandler(t *testing.T) {
+	bufInfo := &bytes.Buffer{}
+	bufDebug := &bytes.Buffer{}
+
+	h := NewMultiHandler(
+		slog.NewTextHandler(bufInfo, &slog.HandlerOptions{Level: slog.LevelInfo}),
+		slog.NewJSONHandler(bufDebug, &slog.HandlerOptions{Level: slog.LevelDebug}),
+	)
+
+	logger := slog.New(h).With("a", 1).WithGroup("g")
+
+	logger.Debug("debug", "b", 2)
+	logger.Info("info", "b", 3)
+
+	assert.Equal(t, strings.Count(bufInfo.String(), "\n"), 1)
+	assert.Contains(t, bufInfo.String(), "msg=info a=1 g.b=3")
+	assert.Equal(t, strings.Count(bufDebug.String(), "\n"), 2)
+	assert.Contains(t, bufDebug.String(), `"msg":"debug","a":1,"g":{"b":2}`)
+
+	assert.False(t, NewMultiHandler(slog.NewTextHandler(bufInfo, nil)).Enabled(context.Background(), slog.LevelDebug))
+
+	// a failed handler does not stop the others
+	bufDebug.Reset()
+
+	h = NewMultiHandler(slog.NewTextHandler(&errLogWriter{}, nil), slog.NewTextHandler(bufDebug, nil))
+
+	r := slog.NewRecord(time.Time{}, slog.LevelInfo, "msg", 0)
+	assert.Error(t, h.Handle(context.Background(), r))
+	assert.Equal(t, bufDebug.String(), "level=INFO msg=msg\n")
+
+	t.Logf("Test_MultiHandler OK")
+}
+
+func Test_NewLoggerOutputs(t *testing.T) {
+	dir := t.TempDir()
+
+	fnConsole := path.Join(dir, "console.log")
+	fnDebug := path.Join(dir, "debug.log")
+	fnError := path.Join(dir, "error.log")
+
+	logger, err := NewLogger(&LoggerConfig{
+		Level:  "debug",
+		Format: LoggerFormatJSON,
+		Outputs: []*LoggerOutput{
+			{Type: LoggerOutputFile, Filename: fnConsole, Level: "info", Format: LoggerFormatConsole},
+			{Type: LoggerOutputFile, Filename: fnDebug},
+			{Type: LoggerOutputFile, Filename: fnError, Level: "error"},
+		},
+	})
+	assert.NoError(t, err)
+
+	logger.Debug("debug")
+	logger.Info("info")
+	logger.Error("error", Err(ErrNoMsgName))
+
+	data, err := os.ReadFile(fnConsole)
+	assert.NoError(t, err)
+	assert.Equal(t, strings.Count(string(data), "\n"), 2)
+	assert.Contains(t, string(data), "INFO  info\n")
+	assert.Contains(t, string(data), "ERROR error err=\"no MsgName\"\n")
+
+	data, err = os.ReadFile(fnDebug)
+	assert.NoError(t, err)
+	assert.Equal(t, strings.Count(string(data), "\n"), 3)
+	assert.Contains(t, string(data), `"level":"DEBUG","msg":"debug"`)
+
+	data, err = os.ReadFile(fnError)
+	assert.NoError(t, err)
+	assert.Equal(t, strings.Count(string(data), "\n"), 1)
+	assert.Contains(t, string(data), `"level":"ERROR","msg":"error","err":"no MsgName"`)
+
+	_, err = NewLogger(&LoggerConfig{Outputs: []*LoggerOutput{{Type: LoggerOutputStderr, Level: "verbose"}}})
+	assert.ErrorIs(t, err, ErrInvalidLoggerLevel)
+
+	_, err = NewLogger(&LoggerConfig{Outputs: []*LoggerOutput{{Type: LoggerOutputStderr, Format: "xml"}}})
+	assert.ErrorIs(t, err, ErrInvalidLoggerFormat)
+
+	t.Logf("Test_NewLoggerOutputs OK")
+}
+
+func Test_NewLoggerFixedOutputLevel(t *testing.T) {
+	dir := t.TempDir()
+
+	fnConsole := path.Join(dir, "console.log")
+	fnDebug := path.Join(dir, "debug.log")
+
+	logger, lc, err := NewLoggerWithController(&LoggerConfig{
+		Level:  "info",
+		Format: LoggerFormatJSON,
+		Outputs: []*LoggerOutput{
+			{Type: LoggerOutputFile, Filename: fnConsole},
+			{Type: LoggerOutputFile, Filename: fnDebug, Level: "debug"},
+		},
+	})
+	assert.NoError(t, err)
+
+	logger.Debug("debug")
+	logger.Info("info")
+
+	lc.SetLevel(slog.LevelWarn)
+	logger.Info("info2")
+
+	lc.SetNameLevel("net", slog.LevelDebug)
+	lc.Logger(logger, "net").Debug("netdebug")
+
+	data, err := os.ReadFile(fnConsole)
+	assert.NoError(t, err)
+	assert.Equal(t, strings.Count(string(data), "\n"), 2)
+	assert.Contains(t, string(data), `"msg":"info"`)
+	assert.Contains(t, string(data), `"msg":"netdebug"`)
+
+	data, err = os.ReadFile(fnDebug)
+	assert.NoError(t, err)
+	assert.Equal(t, strings.Count(string(data), "\n"), 4)
+	assert.Contains(t, string(data), `"level":"DEBUG","msg":"debug"`)
+	assert.Contains(t, string(data), `"msg":"info2"`)
+
+	t.Logf("Test_NewLoggerFixedOutputLevel OK")
+}
@@ -0,0 +1,300 @@
+package goutils
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+const (
+	// RedactModeMask - the sensitive values are replaced with RedactOptions.Mask
+	RedactModeMask = "mask"
+	// RedactModeHash - the sensitive values are replaced with "sha256:" + the first 16 hex chars of their hash,
+	// so the same values can still be correlated
+	RedactModeHash = "hash"
+)
+
+// DefaultRedactMask - default mask of RedactModeMask
+const DefaultRedactMask = "***"
+
+var (
+	// RedactPatternEmail - email addresses
+	RedactPatternEmail = `[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`
+	// RedactPatternBearer - bearer tokens in Authorization headers
+	RedactPatternBearer = `(?i)bearer\s+[A-Za-z0-9\-._~+/]+=*`
+	// RedactPatternJWT - JSON web tokens
+	RedactPatternJWT = `eyJ[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]+`
+	// RedactPatternCardNumber - card numbers, 13 to 19 digits with optional spaces or dashes,
+	// only the numbers passing the Luhn check are redacted, so ids and timestamps are kept
+	RedactPatternCardNumber = `\b(?:\d[ \-]?){12,18}\d\b`
+
+	// DefaultRedactKeys - keys of sensitive values, case-insensitive
+	DefaultRedactKeys = []string{"password", "passwd", "secret", "token", "accesstoken", "refreshtoken", "authorization", "cookie", "apikey"}
+	// DefaultRedactValuePatterns - emails, bearer tokens, JWTs and card numbers
+	DefaultRedactValuePatterns = []string{RedactPatternEmail, RedactPatternBearer, RedactPatternJWT, RedactPatternCardNumber}
+)
+
+// RedactOptions - options of RedactHandler
+type RedactOptions struct {
+	// Keys - the values of these keys are redacted, case-insensitive
+	Keys []string `json:"keys,omitempty" yaml:"keys,omitempty"`
+	// KeyPatterns - the values of the keys matching these regexps are redacted
+	KeyPatterns []string `json:"keyPatterns,omitempty" yaml:"keyPatterns,omitempty"`
+	// ValuePatterns - the substrings matching these regexps are redacted, in string values and in the message
+	ValuePatterns []string `json:"valuePatterns,omitempty" yaml:"valuePatterns,omitempty"`
+	// Mode - mask or hash, default is mask
+	Mode string `json:"mode,omitempty" yaml:"mode,omitempty"`
+	// Mask - default is DefaultRedactMask
+	Mask string `json:"mask,omitempty" yaml:"mask,omitempty"`
+}
+
+// DefaultRedactOptions - DefaultRedactKeys and DefaultRedactValuePatterns, masked
+func DefaultRedactOptions() *RedactOptions {
+	return &RedactOptions{
+		Keys:          DefaultRedactKeys,
+		ValuePatterns: DefaultRedactValuePatterns,
+		Mode:          RedactModeMask,
+		Mask:          DefaultRedactMask,
+	}
+}
+
+// redactPattern - a compiled value pattern, the matches are redacted only if isValid returns true
+type redactPattern struct {
+	re      *regexp.Regexp
+	isValid func(str string) bool
+}
+
+// redactor - compiled RedactOptions
+type redactor struct {
+	keys          map[string]bool
+	keyPatterns   []*regexp.Regexp
+	valuePatterns []*redactPattern
+	isHash        bool
+	mask          string
+}
+
+func newRedactor(opts *RedactOptions) (*redactor, error) {
+	rd := &redactor{
+		keys:   make(map[string]bool),
+		isHash: opts.Mode == RedactModeHash,
+		mask:   opts.Mask,
+	}
+
+	if opts.Mode != "" && opts.Mode != RedactModeMask && opts.Mode != RedactModeHash {
+		return nil, ErrInvalidRedactMode
+	}
+
+	if rd.mask == "" {
+		rd.mask = DefaultRedactMask
+	}
+
+	for _, k := range opts.Keys {
+		rd.keys[strings.ToLower(k)] = true
+	}
+
+	for _, str := range opts.KeyPatterns {
+		re, err := regexp.Compile(str)
+		if err != nil {
+			return nil, err
+		}
+
+		rd.keyPatterns = append(rd.keyPatterns, re)
+	}
+
+	for _, str := range opts.ValuePatterns {
+		re, err := regexp.Compile(str)
+		if err != nil {
+			return nil, err
+		}
+
+		vp := &redactPattern{re: re}
+		if str == RedactPatternCardNumber {
+			vp.isValid = isLuhnValid
+		}
+
+		rd.valuePatterns = append(rd.valuePatterns, vp)
+	}
+
+	return rd, nil
+}
+
+func (rd *redactor) isSensitiveKey(key string) bool {
+	if rd.keys[strings.ToLower(key)] {
+		return true
+	}
+
+	for _, re := range rd.keyPatterns {
+		if re.MatchString(key) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (rd *redactor) replace(str string) string {
+	if rd.isHash {
+		h := sha256.Sum256([]byte(str))
+
+		return "sha256:" + hex.EncodeToString(h[:8])
+	}
+
+	return rd.mask
+}
+
+// redactString - returns the string and true if any substring is redacted
+func (rd *redactor) redactString(str string) (string, bool) {
+	isRedacted := false
+
+	for _, vp := range rd.valuePatterns {
+		if vp.re.MatchString(str) {
+			str = vp.re.ReplaceAllStringFunc(str, func(match string) string {
+				if vp.isValid != nil && !vp.isValid(match) {
+					return match
+				}
+
+				isRedacted = true
+
+				return rd.replace(match)
+			})
+		}
+	}
+
+	return str, isRedacted
+}
+
+// isLuhnValid - the Luhn checksum of the digits of str, the other chars are skipped
+func isLuhnValid(str string) bool {
+	sum := 0
+	isDouble := false
+
+	for i := len(str) - 1; i >= 0; i-- {
+		c := str[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+
+		d := int(c - '0')
+		if isDouble {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+
+		sum += d
+		isDouble = !isDouble
+	}
+
+	return sum%10 == 0
+}
+
+// redactAttr - the groups are redacted recursively
+func (rd *redactor) redactAttr(a slog.Attr) slog.Attr {
+	a.Value = a.Value.Resolve()
+
+	if a.Key != "" && rd.isSensitiveKey(a.Key) {
+		if rd.isHash && a.Value.Kind() != slog.KindGroup {
+			return slog.String(a.Key, rd.replace(a.Value.String()))
+		}
+
+		return slog.String(a.Key, rd.mask)
+	}
+
+	switch a.Value.Kind() {
+	case slog.KindGroup:
+		attrs := a.Value.Group()
+
+		lst := make([]slog.Attr, 0, len(attrs))
+		for _, ga := range attrs {
+			lst = append(lst, rd.redactAttr(ga))
+		}
+
+		return slog.Attr{Key: a.Key, Value: slog.GroupValue(lst...)}
+	case slog.KindString:
+		str, isRedacted := rd.redactString(a.Value.String())
+		if isRedacted {
+			return slog.String(a.Key, str)
+		}
+	case slog.KindAny:
+		if len(rd.valuePatterns) > 0 {
+			str, isRedacted := rd.redactString(fmt.Sprint(a.Value.Any()))
+			if isRedacted {
+				return slog.String(a.Key, str)
+			}
+		}
+	}
+
+	return a
+}
+
+func (rd *redactor) redactAttrs(attrs []slog.Attr) []slog.Attr {
+	lst := make([]slog.Attr, 0, len(attrs))
+	for _, a := range attrs {
+		lst = append(lst, rd.redactAttr(a))
+	}
+
+	return lst
+}
+
+// RedactHandler - redacts the sensitive values of every record, including the attrs in nested groups and of WithAttrs.
+// A value of KindAny is formatted with fmt.Sprint, it is replaced with the redacted string only if it matches a value pattern.
+type RedactHandler struct {
+	handler slog.Handler
+	rd      *redactor
+}
+
+// NewRedactHandler - new RedactHandler, opts is DefaultRedactOptions() if it is nil
+func NewRedactHandler(handler slog.Handler, opts *RedactOptions) (*RedactHandler, error) {
+	if opts == nil {
+		opts = DefaultRedactOptions()
+	}
+
+	rd, err := newRedactor(opts)
+	if err != nil {
+		Error("NewRedactHandler:newRedactor",
+			Err(err))
+
+		return nil, err
+	}
+
+	return &RedactHandler{
+		handler: handler,
+		rd:      rd,
+	}, nil
+}
+
+func (h *RedactHandler) Enabled(ctx context.Context, lv slog.Level) bool {
+	return h.handler.Enabled(ctx, lv)
+}
+
+func (h *RedactHandler) Handle(ctx context.Context, r slog.Record) error {
+	msg, _ := h.rd.redactString(r.Message)
+
+	nr := slog.NewRecord(r.Time, r.Level, msg, r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		nr.AddAttrs(h.rd.redactAttr(a))
+
+		return true
+	})
+
+	return h.handler.Handle(ctx, nr)
+}
+
+func (h *RedactHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &RedactHandler{
+		handler: h.handler.WithAttrs(h.rd.redactAttrs(attrs)),
+		rd:      h.rd,
+	}
+}
+
+func (h *RedactHandler) WithGroup(name string) slog.Handler {
+	return &RedactHandler{
+		handler: h.handler.WithGroup(name),
+		rd:      h.rd,
+	}
+}
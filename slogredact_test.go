@@ -0,0 +1,101 @@
+package goutils
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newRedactTestLogger(t *testing.T, opts *RedactOptions) (*slog.Logger, *bytes.Buffer) {
+	buf := &bytes.Buffer{}
+
+	h, err := NewRedactHandler(slog.NewJSONHandler(buf, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+
+			return a
+		},
+	}), opts)
+	assert.NoError(t, err)
+
+	return slog.New(h), buf
+}
+
+type redactTestPlayer struct {
+	Name  string
+	Email string
+}
+
+func Test_RedactHandler(t *testing.T) {
+	logger, buf := newRedactTestLogger(t, nil)
+
+	logger.With("token", "abc").Info("login user@example.com",
+		slog.String("Password", "123456"),
+		slog.Group("player",
+			slog.Int("id", 1),
+			slog.String("email", "user@example.com"),
+			slog.Group("auth", slog.String("authorization", "Bearer abc.def"))),
+		slog.String("card", "card 4111 1111 1111 1111 ok"),
+		slog.String("order", "order 1597647600123456"),
+		slog.String("jwt", "eyJhbGciOiJIUzI1NiJ9.eyJzdWIiOiIxIn0.sig"),
+		slog.Any("obj", &redactTestPlayer{Name: "a", Email: "user@example.com"}),
+		slog.Any("err", errors.New("no player")),
+		slog.Int("n", 4111111111111111))
+
+	assert.Equal(t, buf.String(), `{"level":"INFO","msg":"login ***","token":"***","Password":"***",`+
+		`"player":{"id":1,"email":"***","auth":{"authorization":"***"}},`+
+		`"card":"card *** ok","order":"order 1597647600123456","jwt":"***","obj":"&{a ***}","err":"no player","n":4111111111111111}`+"\n")
+
+	t.Logf("Test_RedactHandler OK")
+}
+
+func Test_RedactHandlerHash(t *testing.T) {
+	logger, buf := newRedactTestLogger(t, &RedactOptions{
+		KeyPatterns:   []string{`(?i)secret$`},
+		ValuePatterns: []string{RedactPatternEmail},
+		Mode:          RedactModeHash,
+	})
+
+	logger.WithGroup("g").Info("hash",
+		slog.String("appSecret", "s1"),
+		slog.String("email", "mail a@b.com"),
+		slog.String("password", "not a key"))
+
+	assert.Equal(t, buf.String(), `{"level":"INFO","msg":"hash","g":{"appSecret":"sha256:e8bc163c82eee187",`+
+		`"email":"mail sha256:fb98d44ad7501a95","password":"not a key"}}`+"\n")
+
+	_, err := NewRedactHandler(slog.NewJSONHandler(buf, nil), &RedactOptions{Mode: "drop"})
+	assert.ErrorIs(t, err, ErrInvalidRedactMode)
+
+	_, err = NewRedactHandler(slog.NewJSONHandler(buf, nil), &RedactOptions{KeyPatterns: []string{"("}})
+	assert.Error(t, err)
+
+	t.Logf("Test_RedactHandlerHash OK")
+}
+
+func Test_NewLoggerRedact(t *testing.T) {
+	fn := path.Join(t.TempDir(), "app.log")
+
+	logger, err := NewLogger(&LoggerConfig{
+		Outputs: []*LoggerOutput{{Type: LoggerOutputFile, Filename: fn}},
+		Redact:  DefaultRedactOptions(),
+	})
+	assert.NoError(t, err)
+
+	ctx := WithLogAttrs(context.Background(), slog.String("token", "abc"))
+	logger.InfoContext(ctx, "redact", slog.String("password", "123456"))
+
+	data, err := os.ReadFile(fn)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"msg":"redact","password":"***","token":"***"`)
+
+	t.Logf("Test_NewLoggerRedact OK")
+}
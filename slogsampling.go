@@ -0,0 +1,261 @@
+package goutils
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SamplingSummaryMsg - the message of the summaries of SamplingHandler
+const SamplingSummaryMsg = "suppressed log messages"
+
+// SamplingOptions - options of SamplingHandler, all counters are reset every Window
+type SamplingOptions struct {
+	// Window - the window of the counters, default is 10s
+	Window time.Duration
+	// First - the first N records of a message are logged in a window
+	First int64
+	// Thereafter - then every Mth record of a message is logged, 0 drops all of them
+	Thereafter int64
+	// Dedup - the records with the same level, message, groups and attrs are logged once in a window,
+	// the attrs of WithAttrs are included
+	Dedup bool
+	// Timer - the clock of the windows, default is time.Now
+	Timer ITime
+}
+
+// DefaultSamplingOptions - 10s window, first 10, thereafter every 100th, dedup
+func DefaultSamplingOptions() *SamplingOptions {
+	return &SamplingOptions{
+		Window:     10 * time.Second,
+		First:      10,
+		Thereafter: 100,
+		Dedup:      true,
+	}
+}
+
+// samplingEntry - the counters of a message in a window
+type samplingEntry struct {
+	level      slog.Level
+	message    string
+	start      time.Time
+	times      int64
+	suppressed int64
+}
+
+// samplingState - shared by the handlers of WithAttrs and WithGroup
+type samplingState struct {
+	opts      SamplingOptions
+	handler   slog.Handler
+	lock      sync.Mutex
+	entries   map[string]*samplingEntry
+	dedup     map[string]time.Time
+	lastSweep time.Time
+}
+
+// SamplingHandler - samples the records of every message, and logs a summary of the suppressed records
+// with the message SamplingSummaryMsg when a window is finished.
+// The summaries are logged lazily by the next record after the window, or by Flush.
+type SamplingHandler struct {
+	handler  slog.Handler
+	state    *samplingState
+	attrsKey string
+}
+
+// NewSamplingHandler - new SamplingHandler, opts is DefaultSamplingOptions() if it is nil
+func NewSamplingHandler(handler slog.Handler, opts *SamplingOptions) *SamplingHandler {
+	if opts == nil {
+		opts = DefaultSamplingOptions()
+	}
+
+	state := &samplingState{
+		opts:    *opts,
+		handler: handler,
+		entries: make(map[string]*samplingEntry),
+		dedup:   make(map[string]time.Time),
+	}
+
+	if state.opts.Window <= 0 {
+		state.opts.Window = 10 * time.Second
+	}
+
+	if state.opts.Timer == nil {
+		state.opts.Timer = gTime
+	}
+
+	return &SamplingHandler{
+		handler: handler,
+		state:   state,
+	}
+}
+
+func (h *SamplingHandler) Enabled(ctx context.Context, lv slog.Level) bool {
+	return h.handler.Enabled(ctx, lv)
+}
+
+func (h *SamplingHandler) Handle(ctx context.Context, r slog.Record) error {
+	isLog, summaries := h.state.check(r, h.attrsKey)
+
+	h.state.logSummaries(ctx, summaries)
+
+	if !isLog {
+		return nil
+	}
+
+	return h.handler.Handle(ctx, r)
+}
+
+func (h *SamplingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	var sb strings.Builder
+
+	sb.WriteString(h.attrsKey)
+	for _, a := range attrs {
+		sb.WriteString(a.String())
+		sb.WriteByte(' ')
+	}
+
+	return &SamplingHandler{
+		handler:  h.handler.WithAttrs(attrs),
+		state:    h.state,
+		attrsKey: sb.String(),
+	}
+}
+
+func (h *SamplingHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+
+	return &SamplingHandler{
+		handler:  h.handler.WithGroup(name),
+		state:    h.state,
+		attrsKey: h.attrsKey + name + "=[",
+	}
+}
+
+// Flush - log the summaries of all suppressed records now, and reset all counters
+func (h *SamplingHandler) Flush(ctx context.Context) {
+	h.state.lock.Lock()
+	summaries := h.state.sweep(time.Time{})
+	h.state.lock.Unlock()
+
+	h.state.logSummaries(ctx, summaries)
+}
+
+// check - returns true if r should be logged, and the summaries of the finished windows,
+// attrsKey is the groups and attrs of the handler
+func (state *samplingState) check(r slog.Record, attrsKey string) (bool, []*samplingEntry) {
+	now := state.opts.Timer.Now()
+
+	state.lock.Lock()
+	defer state.lock.Unlock()
+
+	var summaries []*samplingEntry
+	if now.Sub(state.lastSweep) >= state.opts.Window {
+		summaries = state.sweep(now)
+		state.lastSweep = now
+	}
+
+	key := r.Level.String() + "\x00" + r.Message
+
+	entry, isok := state.entries[key]
+	if !isok || now.Sub(entry.start) >= state.opts.Window {
+		if isok && entry.suppressed > 0 {
+			summaries = append(summaries, entry)
+		}
+
+		entry = &samplingEntry{
+			level:   r.Level,
+			message: r.Message,
+			start:   now,
+		}
+
+		state.entries[key] = entry
+	}
+
+	if state.opts.Dedup {
+		dkey := key + "\x00" + attrsKey + samplingAttrsKey(r)
+
+		start, isok := state.dedup[dkey]
+		if isok && now.Sub(start) < state.opts.Window {
+			entry.suppressed++
+
+			return false, summaries
+		}
+
+		state.dedup[dkey] = now
+	}
+
+	entry.times++
+
+	if entry.times <= state.opts.First {
+		return true, summaries
+	}
+
+	if state.opts.Thereafter > 0 && (entry.times-state.opts.First)%state.opts.Thereafter == 0 {
+		return true, summaries
+	}
+
+	entry.suppressed++
+
+	return false, summaries
+}
+
+// sweep - remove the finished windows, returns the entries with suppressed records.
+// All windows are finished if now is zero. state.lock must be locked.
+func (state *samplingState) sweep(now time.Time) []*samplingEntry {
+	var summaries []*samplingEntry
+
+	for k, entry := range state.entries {
+		if now.IsZero() || now.Sub(entry.start) >= state.opts.Window {
+			if entry.suppressed > 0 {
+				summaries = append(summaries, entry)
+			}
+
+			delete(state.entries, k)
+		}
+	}
+
+	for k, start := range state.dedup {
+		if now.IsZero() || now.Sub(start) >= state.opts.Window {
+			delete(state.dedup, k)
+		}
+	}
+
+	return summaries
+}
+
+func (state *samplingState) logSummaries(ctx context.Context, summaries []*samplingEntry) {
+	for _, entry := range summaries {
+		if !state.handler.Enabled(ctx, entry.level) {
+			continue
+		}
+
+		r := slog.NewRecord(state.opts.Timer.Now(), entry.level, SamplingSummaryMsg, 0)
+		r.AddAttrs(
+			slog.String("suppressedMsg", entry.message),
+			slog.Int64("suppressed", entry.suppressed),
+			slog.Int64("windowStart", entry.start.Unix()))
+
+		err := state.handler.Handle(ctx, r)
+		if err != nil {
+			return
+		}
+	}
+}
+
+// samplingAttrsKey - the attrs of r, like "a=1 b=[c=2]"
+func samplingAttrsKey(r slog.Record) string {
+	var sb strings.Builder
+
+	r.Attrs(func(a slog.Attr) bool {
+		sb.WriteString(a.String())
+		sb.WriteByte(' ')
+
+		return true
+	})
+
+	return sb.String()
+}
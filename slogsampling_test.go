@@ -0,0 +1,117 @@
+package goutils
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	gomock "github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_SamplingHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	now := time.Unix(1597647600, 0)
+
+	m := NewMockITime(ctrl)
+	m.EXPECT().Now().DoAndReturn(func() time.Time {
+		return now
+	}).AnyTimes()
+
+	buf := &bytes.Buffer{}
+	h := NewSamplingHandler(slog.NewTextHandler(buf, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+
+			return a
+		},
+	}), &SamplingOptions{
+		Window:     10 * time.Second,
+		First:      2,
+		Thereafter: 3,
+		Dedup:      true,
+		Timer:      m,
+	})
+
+	logger := slog.New(h)
+
+	// first 2, then every 3rd
+	for i := 0; i < 10; i++ {
+		logger.With("i", i).Error("GetJsonIntArr", slog.Int("index", i))
+	}
+
+	assert.Equal(t, strings.Count(buf.String(), "msg=GetJsonIntArr"), 4)
+	assert.Contains(t, buf.String(), "i=1 index=1")
+	assert.Contains(t, buf.String(), "i=4 index=4")
+	assert.Contains(t, buf.String(), "i=7 index=7")
+
+	buf.Reset()
+
+	// dedup
+	logger.Warn("dup", slog.Int("a", 1))
+	logger.Warn("dup", slog.Int("a", 1))
+	logger.Warn("dup", slog.Int("a", 2))
+	logger.Warn("dup", slog.Int("a", 1))
+
+	assert.Equal(t, buf.String(), "level=WARN msg=dup a=1\nlevel=WARN msg=dup a=2\n")
+
+	buf.Reset()
+
+	// the next window
+	now = now.Add(10 * time.Second)
+
+	logger.Warn("dup", slog.Int("a", 1))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Equal(t, len(lines), 3)
+	assert.Contains(t, lines, `level=ERROR msg="suppressed log messages" suppressedMsg=GetJsonIntArr suppressed=6 windowStart=1597647600`)
+	assert.Contains(t, lines, `level=WARN msg="suppressed log messages" suppressedMsg=dup suppressed=2 windowStart=1597647600`)
+	assert.Equal(t, lines[2], "level=WARN msg=dup a=1")
+
+	buf.Reset()
+
+	logger.Warn("dup", slog.Int("a", 1))
+	h.Flush(context.Background())
+	assert.Equal(t, buf.String(), `level=WARN msg="suppressed log messages" suppressedMsg=dup suppressed=1 windowStart=1597647610`+"\n")
+
+	buf.Reset()
+
+	h.Flush(context.Background())
+	assert.Equal(t, buf.Len(), 0)
+
+	// the attrs and groups of With and WithGroup are in the dedup key
+	logger = slog.New(NewSamplingHandler(slog.NewTextHandler(buf, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+
+			return a
+		},
+	}), &SamplingOptions{
+		Window: 10 * time.Second,
+		First:  100,
+		Dedup:  true,
+		Timer:  m,
+	}))
+
+	logger.With("user", "u1").Warn("dup", slog.Int("a", 1))
+	logger.With("user", "u2").Warn("dup", slog.Int("a", 1))
+	logger.With("user", "u2").Warn("dup", slog.Int("a", 1))
+	logger.WithGroup("g").Warn("dup", slog.Int("a", 1))
+	logger.Warn("dup", slog.Int("a", 1))
+
+	assert.Equal(t, buf.String(), "level=WARN msg=dup user=u1 a=1\n"+
+		"level=WARN msg=dup user=u2 a=1\n"+
+		"level=WARN msg=dup g.a=1\n"+
+		"level=WARN msg=dup a=1\n")
+
+	t.Logf("Test_SamplingHandler OK")
+}
@@ -0,0 +1,277 @@
+package goutils
+
+import (
+	"bytes"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+// StatsDashboardTable - the report of a ServStats or SenderStats
+type StatsDashboardTable struct {
+	Stats   string             `json:"stats"`
+	StartTs int64              `json:"startTs"`
+	EndTs   int64              `json:"endTs"`
+	Items   []*StatsReportItem `json:"items"`
+}
+
+// StatsDashboardData - all tables of StatsDashboard
+type StatsDashboardData struct {
+	ServStats   []*StatsDashboardTable `json:"servStats"`
+	SenderStats []*StatsDashboardTable `json:"senderStats"`
+}
+
+// StatsDashboard - http.Handler, serves the current ServStats and SenderStats as JSON or a HTML table.
+//
+//	format - html or json, default is html
+//	prefix - only the names with this prefix
+//	sort   - name, count, avg, p99, max, total or bytes (bytes is total), default is name
+//	order  - asc or desc, default is asc for name and desc for others
+type StatsDashboard struct {
+	lstServStats []*ServStats
+	lstSender    []*SenderStats
+	lock         sync.Mutex
+}
+
+// NewStatsDashboard - new StatsDashboard
+func NewStatsDashboard() *StatsDashboard {
+	return &StatsDashboard{}
+}
+
+// AddServStats - add a ServStats
+func (dashboard *StatsDashboard) AddServStats(stats *ServStats) {
+	dashboard.lock.Lock()
+	dashboard.lstServStats = append(dashboard.lstServStats, stats)
+	dashboard.lock.Unlock()
+}
+
+// AddSenderStats - add a SenderStats
+func (dashboard *StatsDashboard) AddSenderStats(stats *SenderStats) {
+	dashboard.lock.Lock()
+	dashboard.lstSender = append(dashboard.lstSender, stats)
+	dashboard.lock.Unlock()
+}
+
+// Data - the tables, items are filtered by name prefix and sorted by sortBy
+func (dashboard *StatsDashboard) Data(prefix string, sortBy string, isDesc bool) *StatsDashboardData {
+	dashboard.lock.Lock()
+	lstServStats := append([]*ServStats{}, dashboard.lstServStats...)
+	lstSender := append([]*SenderStats{}, dashboard.lstSender...)
+	dashboard.lock.Unlock()
+
+	data := &StatsDashboardData{
+		ServStats:   []*StatsDashboardTable{},
+		SenderStats: []*StatsDashboardTable{},
+	}
+
+	for _, stats := range lstServStats {
+		data.ServStats = append(data.ServStats, newStatsDashboardTable(stats.prefixFN, stats.Snapshot(), prefix, sortBy, isDesc))
+	}
+
+	for _, stats := range lstSender {
+		data.SenderStats = append(data.SenderStats, newStatsDashboardTable(stats.prefixFN, stats.Snapshot(), prefix, sortBy, isDesc))
+	}
+
+	return data
+}
+
+func newStatsDashboardTable(name string, snapshot *StatsSnapshot, prefix string, sortBy string, isDesc bool) *StatsDashboardTable {
+	table := &StatsDashboardTable{
+		Stats:   name,
+		StartTs: snapshot.StartTs,
+		EndTs:   snapshot.EndTs,
+		Items:   []*StatsReportItem{},
+	}
+
+	for _, item := range snapshot.Report() {
+		if strings.HasPrefix(item.Name, prefix) {
+			table.Items = append(table.Items, item)
+		}
+	}
+
+	sortStatsReportItems(table.Items, sortBy, isDesc)
+
+	return table
+}
+
+// statsReportValue - the value of sortBy, 0 for name or an unknown key
+func statsReportValue(item *StatsReportItem, sortBy string) float64 {
+	switch sortBy {
+	case "count":
+		return float64(item.Times)
+	case "avg":
+		return item.Avg
+	case "p99":
+		return item.P99
+	case "max":
+		return item.Max
+	case "total", "bytes":
+		return item.Total
+	}
+
+	return 0
+}
+
+// sortStatsReportItems - the items with the same value are sorted by name
+func sortStatsReportItems(items []*StatsReportItem, sortBy string, isDesc bool) {
+	sort.SliceStable(items, func(i, j int) bool {
+		vi := statsReportValue(items[i], sortBy)
+		vj := statsReportValue(items[j], sortBy)
+		if vi == vj {
+			if isDesc && sortBy == "name" {
+				return items[i].Name > items[j].Name
+			}
+
+			return items[i].Name < items[j].Name
+		}
+
+		if isDesc {
+			return vi > vj
+		}
+
+		return vi < vj
+	})
+}
+
+// ServeHTTP - http.Handler
+func (dashboard *StatsDashboard) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	prefix := query.Get("prefix")
+	sortBy := query.Get("sort")
+	if sortBy == "" {
+		sortBy = "name"
+	}
+
+	isDesc := sortBy != "name"
+	switch query.Get("order") {
+	case "asc":
+		isDesc = false
+	case "desc":
+		isDesc = true
+	}
+
+	data := dashboard.Data(prefix, sortBy, isDesc)
+
+	if query.Get("format") == "json" {
+		json := jsoniter.ConfigCompatibleWithStandardLibrary
+
+		b, err := json.Marshal(data)
+		if err != nil {
+			Warn("StatsDashboard.ServeHTTP:Marshal",
+				Err(err))
+
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(b)
+
+		return
+	}
+
+	buf := &bytes.Buffer{}
+
+	err := statsDashboardTemplate.Execute(buf, &statsDashboardPage{
+		Prefix: prefix,
+		Sort:   sortBy,
+		Data:   data,
+	})
+	if err != nil {
+		Warn("StatsDashboard.ServeHTTP:Execute",
+			slog.String("url", r.URL.String()),
+			Err(err))
+
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(buf.Bytes())
+}
+
+type statsDashboardPage struct {
+	Prefix string
+	Sort   string
+	Data   *StatsDashboardData
+}
+
+var statsDashboardTemplate = template.Must(template.New("dashboard").Funcs(template.FuncMap{
+	"ms": func(v float64) string {
+		return formatStatsFloat(float64(int64(v*1e6)) / 1e3)
+	},
+	"num": func(v float64) string {
+		return formatStatsFloat(float64(int64(v*100)) / 100)
+	},
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>stats</title>
+<style>
+body { font-family: sans-serif; font-size: 13px; margin: 16px; }
+table { border-collapse: collapse; margin-bottom: 24px; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: right; }
+th:first-child, td:first-child { text-align: left; }
+th { background: #eee; }
+th a { color: #000; text-decoration: none; }
+th.sorted a { text-decoration: underline; }
+tr:nth-child(even) { background: #f8f8f8; }
+</style>
+</head>
+<body>
+<form method="get">
+<input type="text" name="prefix" value="{{.Prefix}}" placeholder="name prefix">
+<input type="hidden" name="sort" value="{{.Sort}}">
+<input type="submit" value="filter">
+<a href="?format=json&amp;prefix={{.Prefix}}&amp;sort={{.Sort}}">json</a>
+</form>
+{{$page := .}}
+{{range .Data.ServStats}}
+<h3>ServStats {{.Stats}}</h3>
+<table>
+<tr>
+<th{{if eq $page.Sort "name"}} class="sorted"{{end}}><a href="?prefix={{$page.Prefix}}&amp;sort=name">name</a></th>
+<th{{if eq $page.Sort "count"}} class="sorted"{{end}}><a href="?prefix={{$page.Prefix}}&amp;sort=count">count</a></th>
+<th>errors</th>
+<th{{if eq $page.Sort "avg"}} class="sorted"{{end}}><a href="?prefix={{$page.Prefix}}&amp;sort=avg">avg (ms)</a></th>
+<th>p50 (ms)</th>
+<th>p90 (ms)</th>
+<th{{if eq $page.Sort "p99"}} class="sorted"{{end}}><a href="?prefix={{$page.Prefix}}&amp;sort=p99">p99 (ms)</a></th>
+<th{{if eq $page.Sort "max"}} class="sorted"{{end}}><a href="?prefix={{$page.Prefix}}&amp;sort=max">max (ms)</a></th>
+<th{{if eq $page.Sort "total"}} class="sorted"{{end}}><a href="?prefix={{$page.Prefix}}&amp;sort=total">total (ms)</a></th>
+</tr>
+{{range .Items}}
+<tr><td>{{.Name}}</td><td>{{.Times}}</td><td>{{.ErrTimes}}</td><td>{{ms .Avg}}</td><td>{{ms .P50}}</td><td>{{ms .P90}}</td><td>{{ms .P99}}</td><td>{{ms .Max}}</td><td>{{ms .Total}}</td></tr>
+{{end}}
+</table>
+{{end}}
+{{range .Data.SenderStats}}
+<h3>SenderStats {{.Stats}}</h3>
+<table>
+<tr>
+<th{{if eq $page.Sort "name"}} class="sorted"{{end}}><a href="?prefix={{$page.Prefix}}&amp;sort=name">name</a></th>
+<th{{if eq $page.Sort "count"}} class="sorted"{{end}}><a href="?prefix={{$page.Prefix}}&amp;sort=count">count</a></th>
+<th{{if eq $page.Sort "avg"}} class="sorted"{{end}}><a href="?prefix={{$page.Prefix}}&amp;sort=avg">avg</a></th>
+<th>p50</th>
+<th>p90</th>
+<th{{if eq $page.Sort "p99"}} class="sorted"{{end}}><a href="?prefix={{$page.Prefix}}&amp;sort=p99">p99</a></th>
+<th{{if eq $page.Sort "max"}} class="sorted"{{end}}><a href="?prefix={{$page.Prefix}}&amp;sort=max">max</a></th>
+<th{{if eq $page.Sort "bytes"}} class="sorted"{{end}}><a href="?prefix={{$page.Prefix}}&amp;sort=bytes">bytes</a></th>
+</tr>
+{{range .Items}}
+<tr><td>{{.Name}}</td><td>{{.Times}}</td><td>{{num .Avg}}</td><td>{{num .P50}}</td><td>{{num .P90}}</td><td>{{num .P99}}</td><td>{{num .Max}}</td><td>{{num .Total}}</td></tr>
+{{end}}
+</table>
+{{end}}
+</body>
+</html>
+`))
@@ -0,0 +1,84 @@
+package goutils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_StatsDashboard(t *testing.T) {
+	dir := t.TempDir()
+
+	servstats := NewServStats(10, 0, time.Hour, dir, 16, "serv")
+	servstats.SetAutoRegMsg(true)
+
+	for i, name := range []string{"game.spin", "game.collect", "user.login"} {
+		for j := 0; j <= i; j++ {
+			node := servstats.StartMsg(name)
+			node.Start = time.Now().Add(-time.Duration(30-i*10) * time.Millisecond)
+			servstats.EndMsg(name, node)
+		}
+	}
+
+	senderstats := NewSenderStats(10, 0, time.Hour, dir, "sender")
+	senderstats.Push("gamestate", 1000)
+	senderstats.Push("chat", 100)
+	senderstats.Push("chat", 100)
+
+	dashboard := NewStatsDashboard()
+	dashboard.AddServStats(servstats)
+	dashboard.AddSenderStats(senderstats)
+
+	json := jsoniter.ConfigCompatibleWithStandardLibrary
+
+	rec := httptest.NewRecorder()
+	dashboard.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/stats?format=json&prefix=game.&sort=p99", nil))
+	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.Equal(t, rec.Header().Get("Content-Type"), "application/json")
+
+	data := &StatsDashboardData{}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), data))
+	assert.Equal(t, len(data.ServStats), 1)
+	assert.Equal(t, data.ServStats[0].Stats, "serv")
+	assert.Equal(t, len(data.ServStats[0].Items), 2)
+	assert.Equal(t, data.ServStats[0].Items[0].Name, "game.spin")
+	assert.Equal(t, data.ServStats[0].Items[1].Name, "game.collect")
+	assert.Equal(t, len(data.SenderStats[0].Items), 0)
+
+	rec = httptest.NewRecorder()
+	dashboard.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/stats?format=json&sort=count&order=asc", nil))
+
+	data = &StatsDashboardData{}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), data))
+	assert.Equal(t, data.ServStats[0].Items[0].Name, "game.spin")
+	assert.Equal(t, data.ServStats[0].Items[2].Name, "user.login")
+	assert.Equal(t, data.SenderStats[0].Items[0].Name, "gamestate")
+
+	rec = httptest.NewRecorder()
+	dashboard.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/stats?format=json&sort=bytes", nil))
+
+	data = &StatsDashboardData{}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), data))
+	assert.Equal(t, data.SenderStats[0].Items[0].Name, "gamestate")
+	assert.Equal(t, data.SenderStats[0].Items[1].Total, 200.0)
+
+	rec = httptest.NewRecorder()
+	dashboard.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/stats?prefix=<b>", nil))
+	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.Equal(t, rec.Header().Get("Content-Type"), "text/html; charset=utf-8")
+	assert.Contains(t, rec.Body.String(), "<h3>ServStats serv</h3>")
+	assert.Contains(t, rec.Body.String(), `value="&lt;b&gt;"`)
+	assert.NotContains(t, rec.Body.String(), "game.spin")
+
+	rec = httptest.NewRecorder()
+	dashboard.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/stats?sort=p99", nil))
+	assert.Contains(t, rec.Body.String(), "<td>game.spin</td>")
+	assert.Contains(t, rec.Body.String(), "<td>chat</td>")
+	assert.Contains(t, rec.Body.String(), `class="sorted"><a href="?prefix=&amp;sort=p99">p99 (ms)</a>`)
+
+	t.Logf("Test_StatsDashboard OK")
+}
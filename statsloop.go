@@ -0,0 +1,129 @@
+package goutils
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// statsLoop - the output goroutine of ServStats, SenderStats and RuntimeStats
+type statsLoop struct {
+	name         string
+	tickerSample *time.Ticker
+	sample       func()
+	lock         sync.Mutex
+	cancel       context.CancelFunc
+	done         chan struct{}
+	isStarted    bool
+	isStopped    bool
+}
+
+func newStatsLoop(name string) *statsLoop {
+	return &statsLoop{
+		name: name,
+	}
+}
+
+// newStatsLoopWithSample - sample is called on every tick of tickerSample
+func newStatsLoopWithSample(name string, tickerSample *time.Ticker, sample func()) *statsLoop {
+	return &statsLoop{
+		name:         name,
+		tickerSample: tickerSample,
+		sample:       sample,
+	}
+}
+
+// stopTickers - stop ticker and tickerSample
+func (loop *statsLoop) stopTickers(ticker *time.Ticker) {
+	ticker.Stop()
+
+	if loop.tickerSample != nil {
+		loop.tickerSample.Stop()
+	}
+}
+
+// start - output on every tick, until ctx is done, a state is received or stop is called,
+// a final output is flushed before the goroutine exits
+func (loop *statsLoop) start(ctx context.Context, ticker *time.Ticker, chanState chan int, output func()) error {
+	loop.lock.Lock()
+	defer loop.lock.Unlock()
+
+	if loop.isStarted || loop.isStopped {
+		return ErrAlreadyStarted
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+
+	loop.isStarted = true
+	loop.cancel = cancel
+	loop.done = make(chan struct{})
+
+	var chanSample <-chan time.Time
+	if loop.tickerSample != nil {
+		chanSample = loop.tickerSample.C
+	}
+
+	go func() {
+		defer close(loop.done)
+		defer cancel()
+		defer output()
+		defer loop.stopTickers(ticker)
+
+		for {
+			select {
+			case <-ctx.Done():
+				Info(loop.name + ":mainLoop:Done")
+
+				return
+			case <-chanState:
+				Info(loop.name + ":mainLoop:ChanState")
+
+				return
+			case <-chanSample:
+				loop.sample()
+			case <-ticker.C:
+				output()
+			}
+		}
+	}()
+
+	return nil
+}
+
+// stop - stop the goroutine and wait for the final output, it flushes an output if start was never called
+func (loop *statsLoop) stop(ticker *time.Ticker, output func()) {
+	loop.lock.Lock()
+
+	if loop.isStopped {
+		loop.lock.Unlock()
+
+		return
+	}
+
+	loop.isStopped = true
+
+	if !loop.isStarted {
+		loop.lock.Unlock()
+
+		loop.stopTickers(ticker)
+		output()
+
+		return
+	}
+
+	loop.cancel()
+	loop.lock.Unlock()
+
+	<-loop.done
+}
+
+// wait - returns when the goroutine exits, or at once if start was never called
+func (loop *statsLoop) wait() {
+	loop.lock.Lock()
+	done := loop.done
+	loop.lock.Unlock()
+
+	if done != nil {
+		<-done
+	}
+}
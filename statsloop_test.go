@@ -0,0 +1,120 @@
+package goutils
+
+import (
+	"context"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	gomock "github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ServStatsLifecycle(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := NewMockITime(ctrl)
+	m.EXPECT().Now().Return(time.Unix(1597647600, 0)).AnyTimes()
+
+	dir := t.TempDir()
+	fn := path.Join(dir, "servstats.1597647600.json")
+
+	// Stop without Start flushes a final Output and does not block
+	stats := NewServStats(10, 0, time.Hour, dir, 16, "servstats")
+	stats.SetTimer(m)
+	stats.Stop()
+	stats.Stop()
+	stats.Wait()
+
+	_, err := os.Stat(fn)
+	assert.NoError(t, err)
+
+	assert.ErrorIs(t, stats.Start(context.Background()), ErrAlreadyStarted)
+
+	os.Remove(fn)
+
+	// Start twice
+	stats = NewServStats(10, 0, time.Hour, dir, 16, "servstats")
+	stats.SetTimer(m)
+	stats.RegMsg("msg")
+
+	assert.NoError(t, stats.Start(context.Background()))
+	assert.ErrorIs(t, stats.Start(context.Background()), ErrAlreadyStarted)
+
+	stats.EndMsg("msg", stats.StartMsg("msg"))
+
+	stats.Stop()
+	stats.Stop()
+	stats.Wait()
+
+	snapshot, err := LoadStatsSnapshots(dir, "servstats", StatsSnapshotTypeServ, true)
+	assert.NoError(t, err)
+	assert.Equal(t, snapshot.Items["msg"].Times, int64(1))
+
+	os.Remove(fn)
+
+	// ctx is done
+	stats = NewServStats(10, 1, time.Hour, dir, 16, "servstats")
+	stats.SetTimer(m)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	assert.NoError(t, stats.Start(ctx))
+
+	cancel()
+	stats.Wait()
+
+	_, err = os.Stat(fn)
+	assert.NoError(t, err)
+
+	stats.Stop()
+
+	t.Logf("Test_ServStatsLifecycle OK")
+}
+
+func Test_SenderStatsLifecycle(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := NewMockITime(ctrl)
+	m.EXPECT().Now().Return(time.Unix(1597647600, 0)).AnyTimes()
+
+	dir := t.TempDir()
+	fn := path.Join(dir, "senderstats.1597647600.json")
+
+	// the ticker outputs until ChanState receives a state
+	stats := NewSenderStats(10, 1, time.Millisecond, dir, "senderstats")
+	stats.SetTimer(m)
+	stats.Push("msg", 100)
+
+	assert.NoError(t, stats.Start(context.Background()))
+	assert.ErrorIs(t, stats.Start(context.Background()), ErrAlreadyStarted)
+
+	time.Sleep(10 * time.Millisecond)
+
+	stats.ChanState <- 0
+	stats.Wait()
+
+	snapshot, err := LoadStatsSnapshots(dir, "senderstats", StatsSnapshotTypeSender, true)
+	assert.NoError(t, err)
+	assert.Equal(t, snapshot.Items["msg"].Total, 100.0)
+
+	os.Remove(fn)
+
+	stats.Stop()
+
+	_, err = os.Stat(fn)
+	assert.True(t, os.IsNotExist(err))
+
+	// Stop without Start
+	stats = NewSenderStats(10, 0, time.Hour, dir, "senderstats")
+	stats.SetTimer(m)
+	stats.Stop()
+	stats.Stop()
+
+	_, err = os.Stat(fn)
+	assert.NoError(t, err)
+
+	t.Logf("Test_SenderStatsLifecycle OK")
+}
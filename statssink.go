@@ -0,0 +1,371 @@
+package goutils
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// StatsDump - one dump of ServStats or SenderStats
+type StatsDump struct {
+	Prefix string
+	Time   time.Time
+	Data   []byte
+	Header []string
+	Rows   [][]string
+}
+
+// StatsSink - an output target of StatsDump
+type StatsSink interface {
+	// Write - write a dump
+	Write(dump *StatsDump) error
+}
+
+// writeStatsDump - fan out dump to all sinks, the errors are only logged
+func writeStatsDump(name string, sinks []StatsSink, dump *StatsDump) {
+	for _, sink := range sinks {
+		err := sink.Write(dump)
+		if err != nil {
+			Warn(name,
+				slog.String("prefix", dump.Prefix),
+				slog.String("sink", fmt.Sprintf("%T", sink)),
+				Err(err))
+		}
+	}
+}
+
+func formatStatsFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// FileStatsSink - writes every dump into <Path>/<prefix>.<unix>.json,
+// or <Path>/<prefix>.<unix>-<N>.json if there is a dump in the same second, like the final Output of Stop
+type FileStatsSink struct {
+	Path     string
+	MaxFiles int
+	MaxAge   time.Duration
+}
+
+// NewFileStatsSink - new FileStatsSink, maxFiles and maxAge are for retention, 0 is unlimited
+func NewFileStatsSink(pathOutput string, maxFiles int, maxAge time.Duration) *FileStatsSink {
+	return &FileStatsSink{
+		Path:     pathOutput,
+		MaxFiles: maxFiles,
+		MaxAge:   maxAge,
+	}
+}
+
+// Write - write dump into a temp file, and link it, so a reader never sees a partial file and no dump is replaced
+func (sink *FileStatsSink) Write(dump *StatsDump) error {
+	f, err := os.CreateTemp(sink.Path, fmt.Sprintf(".%v.*.tmp", dump.Prefix))
+	if err != nil {
+		Warn("FileStatsSink.Write:CreateTemp",
+			slog.String("path", sink.Path),
+			Err(err))
+
+		return err
+	}
+
+	_, err = f.Write(dump.Data)
+	if err != nil {
+		f.Close()
+		os.Remove(f.Name())
+
+		Warn("FileStatsSink.Write:Write",
+			slog.String("fn", f.Name()),
+			Err(err))
+
+		return err
+	}
+
+	err = f.Close()
+	if err != nil {
+		os.Remove(f.Name())
+
+		Warn("FileStatsSink.Write:Close",
+			slog.String("fn", f.Name()),
+			Err(err))
+
+		return err
+	}
+
+	err = os.Chmod(f.Name(), 0644)
+	if err != nil {
+		os.Remove(f.Name())
+
+		Warn("FileStatsSink.Write:Chmod",
+			slog.String("fn", f.Name()),
+			Err(err))
+
+		return err
+	}
+
+	fn, err := linkStatsFile(f.Name(), sink.Path, dump.Prefix, dump.Time.Unix())
+	os.Remove(f.Name())
+	if err != nil {
+		Warn("FileStatsSink.Write:linkStatsFile",
+			slog.String("fn", fn),
+			Err(err))
+
+		return err
+	}
+
+	return sink.clean(dump.Prefix, dump.Time)
+}
+
+// linkStatsFile - link tmpfn to <prefix>.<ts>.json, or <prefix>.<ts>-<N>.json with the smallest free N, returns the file name
+func linkStatsFile(tmpfn string, dir string, prefix string, ts int64) (string, error) {
+	fn := path.Join(dir, fmt.Sprintf("%v.%v.json", prefix, ts))
+
+	for n := 1; ; n++ {
+		err := os.Link(tmpfn, fn)
+		if !os.IsExist(err) {
+			return fn, err
+		}
+
+		fn = path.Join(dir, fmt.Sprintf("%v.%v-%v.json", prefix, ts, n))
+	}
+}
+
+// clean - remove the files out of MaxFiles / MaxAge
+func (sink *FileStatsSink) clean(prefix string, now time.Time) error {
+	if sink.MaxFiles <= 0 && sink.MaxAge <= 0 {
+		return nil
+	}
+
+	type dumpFile struct {
+		fn string
+		ts int64
+	}
+
+	lst, err := ListStatsFiles(sink.Path, prefix)
+	if err != nil {
+		return err
+	}
+
+	// newest first
+	files := []*dumpFile{}
+	for i := len(lst) - 1; i >= 0; i-- {
+		_, ts, _ := splitStatsFileName(path.Base(lst[i]))
+		files = append(files, &dumpFile{fn: lst[i], ts: ts})
+	}
+
+	for i, f := range files {
+		isRemove := sink.MaxFiles > 0 && i >= sink.MaxFiles
+		if sink.MaxAge > 0 && now.Sub(time.Unix(f.ts, 0)) > sink.MaxAge {
+			isRemove = true
+		}
+
+		if isRemove {
+			err = os.Remove(f.fn)
+			if err != nil {
+				Warn("FileStatsSink.clean:Remove",
+					slog.String("fn", f.fn),
+					Err(err))
+
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// ListStatsFiles - all <prefix>.<unix>.json and <prefix>.<unix>-<N>.json files in dir,
+// sorted by prefix, time and N, so the dumps of a prefix are in the order they are written, prefix "" is all prefixes
+func ListStatsFiles(dir string, prefix string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		Warn("ListStatsFiles:ReadDir",
+			slog.String("dir", dir),
+			Err(err))
+
+		return nil, err
+	}
+
+	type statsFile struct {
+		fn     string
+		prefix string
+		ts     int64
+		n      int
+	}
+
+	files := []*statsFile{}
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+
+		name := e.Name()
+		if !strings.HasSuffix(name, ".json") {
+			continue
+		}
+
+		curprefix, ts, n := splitStatsFileName(name)
+		if ts <= 0 || (prefix != "" && curprefix != prefix) {
+			continue
+		}
+
+		files = append(files, &statsFile{fn: path.Join(dir, name), prefix: curprefix, ts: ts, n: n})
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		if files[i].prefix != files[j].prefix {
+			return files[i].prefix < files[j].prefix
+		}
+
+		if files[i].ts != files[j].ts {
+			return files[i].ts < files[j].ts
+		}
+
+		return files[i].n < files[j].n
+	})
+
+	lst := make([]string, 0, len(files))
+	for _, f := range files {
+		lst = append(lst, f.fn)
+	}
+
+	return lst, nil
+}
+
+// CSVStatsSink - appends the rows of every dump into <Path>/<prefix>.csv, the first column is the unix time
+type CSVStatsSink struct {
+	Path string
+	lock sync.Mutex
+}
+
+// NewCSVStatsSink - new CSVStatsSink
+func NewCSVStatsSink(pathOutput string) *CSVStatsSink {
+	return &CSVStatsSink{
+		Path: pathOutput,
+	}
+}
+
+// Write - the header is written if the file is new
+func (sink *CSVStatsSink) Write(dump *StatsDump) error {
+	sink.lock.Lock()
+	defer sink.lock.Unlock()
+
+	fn := path.Join(sink.Path, fmt.Sprintf("%v.csv", dump.Prefix))
+
+	isNew := false
+	_, err := os.Stat(fn)
+	if os.IsNotExist(err) {
+		isNew = true
+	}
+
+	f, err := os.OpenFile(fn, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		Warn("CSVStatsSink.Write:OpenFile",
+			slog.String("fn", fn),
+			Err(err))
+
+		return err
+	}
+	defer f.Close()
+
+	writer := csv.NewWriter(f)
+
+	if isNew {
+		writer.Write(append([]string{"ts"}, dump.Header...))
+	}
+
+	ts := strconv.FormatInt(dump.Time.Unix(), 10)
+	for _, row := range dump.Rows {
+		writer.Write(append([]string{ts}, row...))
+	}
+
+	writer.Flush()
+
+	err = writer.Error()
+	if err != nil {
+		Warn("CSVStatsSink.Write:Flush",
+			slog.String("fn", fn),
+			Err(err))
+
+		return err
+	}
+
+	return nil
+}
+
+// JSONLStatsSink - appends every dump as one line into <Path>/<prefix>.jsonl
+type JSONLStatsSink struct {
+	Path string
+	lock sync.Mutex
+}
+
+// NewJSONLStatsSink - new JSONLStatsSink
+func NewJSONLStatsSink(pathOutput string) *JSONLStatsSink {
+	return &JSONLStatsSink{
+		Path: pathOutput,
+	}
+}
+
+// Write - write a line
+func (sink *JSONLStatsSink) Write(dump *StatsDump) error {
+	sink.lock.Lock()
+	defer sink.lock.Unlock()
+
+	fn := path.Join(sink.Path, fmt.Sprintf("%v.jsonl", dump.Prefix))
+
+	f, err := os.OpenFile(fn, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		Warn("JSONLStatsSink.Write:OpenFile",
+			slog.String("fn", fn),
+			Err(err))
+
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.Write(append(append([]byte{}, dump.Data...), '\n'))
+	if err != nil {
+		Warn("JSONLStatsSink.Write:Write",
+			slog.String("fn", fn),
+			Err(err))
+
+		return err
+	}
+
+	return nil
+}
+
+// SlogStatsSink - logs every dump with a slog.Logger
+type SlogStatsSink struct {
+	Logger *slog.Logger
+	Level  slog.Level
+}
+
+// NewSlogStatsSink - new SlogStatsSink, logger is slog.Default() if it is nil
+func NewSlogStatsSink(logger *slog.Logger, level slog.Level) *SlogStatsSink {
+	return &SlogStatsSink{
+		Logger: logger,
+		Level:  level,
+	}
+}
+
+// Write - the dump is logged as the attr data
+func (sink *SlogStatsSink) Write(dump *StatsDump) error {
+	logger := sink.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	logger.LogAttrs(context.Background(), sink.Level, "stats",
+		slog.String("prefix", dump.Prefix),
+		slog.Int64("ts", dump.Time.Unix()),
+		slog.Any("data", json.RawMessage(dump.Data)))
+
+	return nil
+}
@@ -0,0 +1,148 @@
+package goutils
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	gomock "github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_FileStatsSink(t *testing.T) {
+	dir := t.TempDir()
+
+	sink := NewFileStatsSink(dir, 3, time.Hour)
+	now := time.Unix(1597647600, 0)
+
+	for i := 0; i < 5; i++ {
+		err := sink.Write(&StatsDump{
+			Prefix: "servstats",
+			Time:   now.Add(time.Duration(i) * time.Minute),
+			Data:   []byte(`{}`),
+		})
+		assert.NoError(t, err)
+	}
+
+	lst, err := ListStatsFiles(dir, "servstats")
+	assert.NoError(t, err)
+	assert.Equal(t, len(lst), 3)
+	assert.Equal(t, path.Base(lst[0]), "servstats.1597647720.json")
+
+	// no temp file is left
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, len(entries), 3)
+
+	err = sink.Write(&StatsDump{
+		Prefix: "servstats",
+		Time:   now.Add(2 * time.Hour),
+		Data:   []byte(`{}`),
+	})
+	assert.NoError(t, err)
+
+	lst, err = ListStatsFiles(dir, "servstats")
+	assert.NoError(t, err)
+	assert.Equal(t, len(lst), 1)
+
+	t.Logf("Test_FileStatsSink OK")
+}
+
+func Test_FileStatsSinkSameSecond(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := NewMockITime(ctrl)
+	m.EXPECT().Now().Return(time.Unix(1597647600, 0)).AnyTimes()
+
+	dir := t.TempDir()
+
+	stats := NewServStats(10, 0, time.Hour, dir, 16, "servstats")
+	stats.SetTimer(m)
+	stats.SetResetOnOutput(true)
+	stats.RegMsg("spin")
+
+	for i := 0; i < 5; i++ {
+		stats.EndMsg("spin", stats.StartMsg("spin"))
+	}
+
+	// the final Output of Stop is in the same second, it must not replace the dump of the tick
+	stats.Output()
+	stats.Stop()
+
+	lst, err := ListStatsFiles(dir, "servstats")
+	assert.NoError(t, err)
+	assert.Equal(t, len(lst), 2)
+	assert.Equal(t, path.Base(lst[0]), "servstats.1597647600.json")
+	assert.Equal(t, path.Base(lst[1]), "servstats.1597647600-1.json")
+
+	snapshot, err := LoadStatsSnapshots(dir, "servstats", "", false)
+	assert.NoError(t, err)
+	assert.Equal(t, snapshot.Sources, 2)
+	assert.Equal(t, snapshot.Items["spin"].Times, int64(5))
+
+	prefix, ts, n := splitStatsFileName("servstats.1597647600-12.json")
+	assert.Equal(t, prefix, "servstats")
+	assert.Equal(t, ts, int64(1597647600))
+	assert.Equal(t, n, 12)
+
+	_, ts, _ = splitStatsFileName("servstats.1597647600-x.json")
+	assert.Equal(t, ts, int64(0))
+
+	t.Logf("Test_FileStatsSinkSameSecond OK")
+}
+
+func Test_StatsSinks(t *testing.T) {
+	dir := t.TempDir()
+
+	buf := &bytes.Buffer{}
+	logger := slog.New(slog.NewJSONHandler(buf, nil))
+
+	stats := NewSenderStats(10, 0, time.Hour, dir, "senderstats")
+	stats.AddSink(NewCSVStatsSink(dir))
+	stats.AddSink(NewJSONLStatsSink(dir))
+	stats.AddSink(NewSlogStatsSink(logger, slog.LevelInfo))
+
+	stats.Push("msg", 100)
+	stats.Output()
+
+	stats.Push("msg", 300)
+	stats.Output()
+
+	data, err := os.ReadFile(path.Join(dir, "senderstats.csv"))
+	assert.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	assert.Equal(t, len(lines), 3)
+	assert.Equal(t, lines[0], "ts,name,totalTimes,totalBytes,avgBytes,minBytes,maxBytes,p50,p90,p99")
+	assert.True(t, strings.Contains(lines[2], ",msg,2,400,200,100,300,"))
+
+	data, err = os.ReadFile(path.Join(dir, "senderstats.jsonl"))
+	assert.NoError(t, err)
+	assert.Equal(t, strings.Count(string(data), "\n"), 2)
+
+	assert.Equal(t, strings.Count(buf.String(), `"msg":"stats"`), 2)
+	assert.Contains(t, buf.String(), `"data":{"type":"senderstats",`)
+	assert.Contains(t, buf.String(), `"mapMsgs":{"msg":`)
+
+	servstats := NewServStats(10, 0, time.Hour, dir, 16, "servstats")
+	servstats.SetSinks(NewCSVStatsSink(dir))
+	servstats.RegMsg("spin")
+	servstats.EndMsg("spin", servstats.StartMsg("spin"))
+	servstats.Output()
+
+	lst, err := ListStatsFiles(dir, "servstats")
+	assert.NoError(t, err)
+	assert.Equal(t, len(lst), 0)
+
+	data, err = os.ReadFile(path.Join(dir, "servstats.csv"))
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(data), "ts,name,totalTimes,totalTime,avgTime,minTime,maxTime,p50,p90,p99,p999,errTimes,maxParallels,lastMsgNums\n"))
+	assert.Contains(t, string(data), ",spin,1,")
+
+	t.Logf("Test_StatsSinks OK")
+}
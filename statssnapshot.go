@@ -0,0 +1,526 @@
+package goutils
+
+import (
+	"encoding/csv"
+	"io"
+	"log/slog"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+const (
+	// StatsSnapshotTypeServ - snapshot of ServStats, the values are seconds
+	StatsSnapshotTypeServ = "servstats"
+	// StatsSnapshotTypeSender - snapshot of SenderStats, the values are bytes
+	StatsSnapshotTypeSender = "senderstats"
+	// StatsSnapshotTypeRuntime - the type of the dumps of RuntimeStats, they are not snapshots, LoadStatsSnapshots skips them
+	StatsSnapshotTypeRuntime = "runtimestats"
+)
+
+// StatsSnapshotItem - a message of ServStats, or a name of SenderStats
+type StatsSnapshotItem struct {
+	Name      string     `json:"name"`
+	Times     int64      `json:"times"`
+	Total     float64    `json:"total"`
+	Min       float64    `json:"min"`
+	Max       float64    `json:"max"`
+	ErrTimes  int64      `json:"errTimes,omitempty"`
+	Histogram *Histogram `json:"histogram,omitempty"`
+}
+
+func (item *StatsSnapshotItem) clone() *StatsSnapshotItem {
+	nitem := &StatsSnapshotItem{
+		Name:     item.Name,
+		Times:    item.Times,
+		Total:    item.Total,
+		Min:      item.Min,
+		Max:      item.Max,
+		ErrTimes: item.ErrTimes,
+	}
+
+	if item.Histogram != nil {
+		nitem.Histogram = item.Histogram.Clone()
+	}
+
+	return nitem
+}
+
+func (item *StatsSnapshotItem) merge(o *StatsSnapshotItem) {
+	if o.Times > 0 {
+		if item.Times == 0 || o.Min < item.Min {
+			item.Min = o.Min
+		}
+
+		if item.Times == 0 || o.Max > item.Max {
+			item.Max = o.Max
+		}
+	}
+
+	item.Times += o.Times
+	item.Total += o.Total
+	item.ErrTimes += o.ErrTimes
+
+	if o.Histogram != nil {
+		if item.Histogram == nil {
+			item.Histogram = NewHistogram(o.Histogram.RelativeAccuracy)
+		}
+
+		item.Histogram.Merge(o.Histogram)
+	}
+}
+
+// StatsSnapshot - mergeable statistics of ServStats or SenderStats, from one or more processes
+type StatsSnapshot struct {
+	Type    string                        `json:"type"`
+	StartTs int64                         `json:"startTs"`
+	EndTs   int64                         `json:"endTs"`
+	Sources int                           `json:"sources"`
+	Items   map[string]*StatsSnapshotItem `json:"items"`
+}
+
+func newStatsSnapshot(typ string, startTs int64, endTs int64) *StatsSnapshot {
+	return &StatsSnapshot{
+		Type:    typ,
+		StartTs: startTs,
+		EndTs:   endTs,
+		Sources: 1,
+		Items:   make(map[string]*StatsSnapshotItem),
+	}
+}
+
+// Snapshot - current statistics of stats
+func (stats *ServStats) Snapshot() *StatsSnapshot {
+	stats.lock.RLock()
+	snapshot := newStatsSnapshot(StatsSnapshotTypeServ, stats.StartTs, stats.timer.Now().Unix())
+	stats.lock.RUnlock()
+
+	for _, msg := range stats.sortedMsgs() {
+		msg.lock.Lock()
+
+		item := &StatsSnapshotItem{
+			Name:      msg.Name,
+			Times:     int64(msg.TotalTimes),
+			Total:     msg.TotalTime,
+			Max:       msg.MaxTime,
+			ErrTimes:  int64(msg.ErrTimes),
+			Histogram: msg.Histogram.Clone(),
+		}
+
+		if msg.TotalTimes > 0 {
+			item.Min = msg.MinTime
+		}
+
+		msg.lock.Unlock()
+
+		snapshot.Items[item.Name] = item
+	}
+
+	return snapshot
+}
+
+// Snapshot - current statistics of stats
+func (stats *SenderStats) Snapshot() *StatsSnapshot {
+	stats.lock.Lock()
+	defer stats.lock.Unlock()
+
+	now := stats.timer.Now()
+	stats.collect(now, false)
+
+	snapshot := newStatsSnapshot(StatsSnapshotTypeSender, stats.StartTs, now.Unix())
+
+	for _, node := range stats.MapNodes {
+		item := &StatsSnapshotItem{
+			Name:      node.Name,
+			Times:     int64(node.TotalTimes),
+			Total:     float64(node.TotalBytes),
+			Max:       float64(node.MaxBytes),
+			Histogram: node.Histogram.Clone(),
+		}
+
+		if node.TotalTimes > 0 {
+			item.Min = float64(node.MinBytes)
+		}
+
+		snapshot.Items[item.Name] = item
+	}
+
+	return snapshot
+}
+
+// MergeStatsSnapshot - a + b, a and b are not changed
+func MergeStatsSnapshot(a *StatsSnapshot, b *StatsSnapshot) (*StatsSnapshot, error) {
+	if a.Type != "" && b.Type != "" && a.Type != b.Type {
+		Error("MergeStatsSnapshot",
+			slog.String("a", a.Type),
+			slog.String("b", b.Type),
+			Err(ErrInvalidStatsSnapshotType))
+
+		return nil, ErrInvalidStatsSnapshotType
+	}
+
+	snapshot := &StatsSnapshot{
+		Type:    a.Type,
+		StartTs: a.StartTs,
+		EndTs:   a.EndTs,
+		Sources: a.Sources + b.Sources,
+		Items:   make(map[string]*StatsSnapshotItem),
+	}
+
+	if snapshot.Type == "" {
+		snapshot.Type = b.Type
+	}
+
+	if b.StartTs > 0 && (snapshot.StartTs == 0 || b.StartTs < snapshot.StartTs) {
+		snapshot.StartTs = b.StartTs
+	}
+
+	if b.EndTs > snapshot.EndTs {
+		snapshot.EndTs = b.EndTs
+	}
+
+	for k, v := range a.Items {
+		snapshot.Items[k] = v.clone()
+	}
+
+	for k, v := range b.Items {
+		item, isok := snapshot.Items[k]
+		if isok {
+			item.merge(v)
+		} else {
+			snapshot.Items[k] = v.clone()
+		}
+	}
+
+	return snapshot, nil
+}
+
+// defaultStatsInstance - the hostname
+func defaultStatsInstance() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return ""
+	}
+
+	return hostname
+}
+
+// statsDumpFile - a file dumped by ServStats.Output or SenderStats.Output
+type statsDumpFile struct {
+	Type     string                    `json:"type"`
+	Instance string                    `json:"instance"`
+	MapMsgs  map[string]*statsDumpItem `json:"mapMsgs"`
+	StartTs  int64                     `json:"startTs"`
+	EndTs    int64                     `json:"endTs"`
+}
+
+type statsDumpItem struct {
+	Name       string     `json:"name"`
+	TotalTime  float64    `json:"totalTime"`
+	TotalTimes int64      `json:"totalTimes"`
+	MaxTime    float64    `json:"maxTime"`
+	MinTime    *float64   `json:"minTime"`
+	ErrTimes   int64      `json:"errTimes"`
+	TotalBytes *int64     `json:"totalBytes"`
+	MaxBytes   int64      `json:"maxBytes"`
+	MinBytes   *int64     `json:"minBytes"`
+	Histogram  *Histogram `json:"histogram"`
+}
+
+// ParseStatsSnapshot - parse a file dumped by ServStats.Output or SenderStats.Output
+func ParseStatsSnapshot(data []byte) (*StatsSnapshot, error) {
+	snapshot, _, err := parseStatsDump(data)
+	if err != nil {
+		Warn("ParseStatsSnapshot:parseStatsDump",
+			Err(err))
+
+		return nil, err
+	}
+
+	if snapshot.Type == StatsSnapshotTypeRuntime {
+		Warn("ParseStatsSnapshot",
+			slog.String("type", snapshot.Type),
+			Err(ErrInvalidStatsSnapshotType))
+
+		return nil, ErrInvalidStatsSnapshotType
+	}
+
+	return snapshot, nil
+}
+
+// parseStatsDump - returns the snapshot and the instance name of the dump, the snapshot of a RuntimeStats dump has no items.
+// A dump without the type is written by an older version, it is SenderStats if it has the bytes.
+func parseStatsDump(data []byte) (*StatsSnapshot, string, error) {
+	json := jsoniter.ConfigCompatibleWithStandardLibrary
+
+	dump := &statsDumpFile{}
+	err := json.Unmarshal(data, dump)
+	if err != nil {
+		return nil, "", err
+	}
+
+	typ := dump.Type
+	if typ == "" {
+		typ = StatsSnapshotTypeServ
+
+		for _, v := range dump.MapMsgs {
+			if v.MinBytes != nil || v.TotalBytes != nil {
+				typ = StatsSnapshotTypeSender
+
+				break
+			}
+		}
+	}
+
+	if typ == StatsSnapshotTypeRuntime {
+		return newStatsSnapshot(typ, dump.StartTs, dump.EndTs), dump.Instance, nil
+	}
+
+	if typ != StatsSnapshotTypeServ && typ != StatsSnapshotTypeSender {
+		return nil, "", ErrInvalidStatsSnapshotType
+	}
+
+	snapshot := newStatsSnapshot(typ, dump.StartTs, dump.EndTs)
+
+	for k, v := range dump.MapMsgs {
+		item := &StatsSnapshotItem{
+			Name:      v.Name,
+			Times:     v.TotalTimes,
+			Histogram: v.Histogram,
+		}
+
+		if item.Name == "" {
+			item.Name = k
+		}
+
+		if typ == StatsSnapshotTypeSender {
+			if v.TotalBytes != nil {
+				item.Total = float64(*v.TotalBytes)
+			}
+
+			item.Max = float64(v.MaxBytes)
+
+			if item.Times > 0 && v.MinBytes != nil {
+				item.Min = float64(*v.MinBytes)
+			}
+		} else {
+			item.Total = v.TotalTime
+			item.Max = v.MaxTime
+			item.ErrTimes = v.ErrTimes
+
+			if item.Times > 0 && v.MinTime != nil {
+				item.Min = *v.MinTime
+			}
+		}
+
+		snapshot.Items[item.Name] = item
+	}
+
+	return snapshot, dump.Instance, nil
+}
+
+// LoadStatsSnapshots - load and merge the files of statsType dumped into dir, prefix "" is all prefixes,
+// statsType "" is StatsSnapshotTypeServ, and the files of the other types are skipped.
+// The dumps are cumulative without ResetOnOutput, so isLatestOnly should be true to use only the newest file
+// of every instance, an instance is the instance name (see ServStats.SetInstance) and the prefix of a file.
+func LoadStatsSnapshots(dir string, prefix string, statsType string, isLatestOnly bool) (*StatsSnapshot, error) {
+	if statsType == "" {
+		statsType = StatsSnapshotTypeServ
+	}
+
+	if statsType != StatsSnapshotTypeServ && statsType != StatsSnapshotTypeSender {
+		Warn("LoadStatsSnapshots",
+			slog.String("statsType", statsType),
+			Err(ErrInvalidStatsSnapshotType))
+
+		return nil, ErrInvalidStatsSnapshotType
+	}
+
+	lst, err := ListStatsFiles(dir, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := []string{}
+	snapshots := make(map[string]*StatsSnapshot)
+	latestTs := make(map[string]int64)
+
+	for _, fn := range lst {
+		data, err := os.ReadFile(fn)
+		if err != nil {
+			Warn("LoadStatsSnapshots:ReadFile",
+				slog.String("fn", fn),
+				Err(err))
+
+			return nil, err
+		}
+
+		cur, instance, err := parseStatsDump(data)
+		if err != nil {
+			Warn("LoadStatsSnapshots:parseStatsDump",
+				slog.String("fn", fn),
+				Err(err))
+
+			return nil, err
+		}
+
+		if cur.Type != statsType {
+			continue
+		}
+
+		key := fn
+		curprefix, ts, _ := splitStatsFileName(path.Base(fn))
+		if isLatestOnly {
+			key = instance + "\x00" + curprefix
+		}
+
+		last, isok := snapshots[key]
+		if !isok {
+			keys = append(keys, key)
+		} else if ts < latestTs[key] || (ts == latestTs[key] && cur.EndTs < last.EndTs) {
+			continue
+		}
+
+		snapshots[key] = cur
+		latestTs[key] = ts
+	}
+
+	sort.Strings(keys)
+
+	snapshot := newStatsSnapshot(statsType, 0, 0)
+	snapshot.Sources = 0
+
+	for _, key := range keys {
+		snapshot, err = MergeStatsSnapshot(snapshot, snapshots[key])
+		if err != nil {
+			Warn("LoadStatsSnapshots:MergeStatsSnapshot",
+				slog.String("key", key),
+				Err(err))
+
+			return nil, err
+		}
+	}
+
+	return snapshot, nil
+}
+
+// splitStatsFileName - "servstats.1597647600.json" -> "servstats", 1597647600, 0,
+// "servstats.1597647600-2.json" -> "servstats", 1597647600, 2
+func splitStatsFileName(fn string) (string, int64, int) {
+	str := strings.TrimSuffix(fn, ".json")
+
+	i := strings.LastIndex(str, ".")
+	if i < 0 {
+		return str, 0, 0
+	}
+
+	strts := str[i+1:]
+	n := 0
+
+	j := strings.Index(strts, "-")
+	if j >= 0 {
+		cn, err := strconv.Atoi(strts[j+1:])
+		if err != nil || cn <= 0 {
+			return str, 0, 0
+		}
+
+		strts = strts[:j]
+		n = cn
+	}
+
+	ts, err := strconv.ParseInt(strts, 10, 64)
+	if err != nil {
+		return str, 0, 0
+	}
+
+	return str[:i], ts, n
+}
+
+// StatsReportItem - a row of StatsSnapshot.Report
+type StatsReportItem struct {
+	Name     string  `json:"name"`
+	Times    int64   `json:"times"`
+	Total    float64 `json:"total"`
+	Avg      float64 `json:"avg"`
+	Min      float64 `json:"min"`
+	Max      float64 `json:"max"`
+	P50      float64 `json:"p50"`
+	P90      float64 `json:"p90"`
+	P99      float64 `json:"p99"`
+	P999     float64 `json:"p999"`
+	ErrTimes int64   `json:"errTimes"`
+}
+
+// Report - one row per item, sorted by name
+func (snapshot *StatsSnapshot) Report() []*StatsReportItem {
+	lst := make([]*StatsReportItem, 0, len(snapshot.Items))
+
+	for _, item := range snapshot.Items {
+		ri := &StatsReportItem{
+			Name:     item.Name,
+			Times:    item.Times,
+			Total:    item.Total,
+			Min:      item.Min,
+			Max:      item.Max,
+			ErrTimes: item.ErrTimes,
+		}
+
+		if item.Times > 0 {
+			ri.Avg = item.Total / float64(item.Times)
+		}
+
+		if item.Histogram != nil {
+			ri.P50 = item.Histogram.Percentile(50)
+			ri.P90 = item.Histogram.Percentile(90)
+			ri.P99 = item.Histogram.Percentile(99)
+			ri.P999 = item.Histogram.Percentile(99.9)
+		}
+
+		lst = append(lst, ri)
+	}
+
+	sort.Slice(lst, func(i, j int) bool {
+		return lst[i].Name < lst[j].Name
+	})
+
+	return lst
+}
+
+// OutputReportCSV - write Report as csv
+func (snapshot *StatsSnapshot) OutputReportCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+
+	writer.Write([]string{"name", "times", "total", "avg", "min", "max", "p50", "p90", "p99", "p999", "errTimes"})
+
+	for _, ri := range snapshot.Report() {
+		writer.Write([]string{
+			ri.Name,
+			strconv.FormatInt(ri.Times, 10),
+			formatStatsFloat(ri.Total),
+			formatStatsFloat(ri.Avg),
+			formatStatsFloat(ri.Min),
+			formatStatsFloat(ri.Max),
+			formatStatsFloat(ri.P50),
+			formatStatsFloat(ri.P90),
+			formatStatsFloat(ri.P99),
+			formatStatsFloat(ri.P999),
+			strconv.FormatInt(ri.ErrTimes, 10),
+		})
+	}
+
+	writer.Flush()
+
+	err := writer.Error()
+	if err != nil {
+		Warn("StatsSnapshot.OutputReportCSV:Flush",
+			Err(err))
+
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,194 @@
+package goutils
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	gomock "github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_LoadStatsSnapshots(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := NewMockITime(ctrl)
+	m.EXPECT().Now().Return(time.Unix(1597647600, 0)).AnyTimes()
+
+	dir := t.TempDir()
+
+	for i, prefix := range []string{"game1", "game2"} {
+		stats := NewServStats(10, 0, time.Hour, dir, 16, prefix)
+		stats.SetTimer(m)
+		stats.RegMsg("spin")
+
+		for j := 0; j < 10*(i+1); j++ {
+			node := stats.StartMsg("spin")
+			node.Start = time.Now().Add(-time.Duration(10*(i+1)) * time.Millisecond)
+			stats.EndMsg("spin", node)
+		}
+
+		stats.Output()
+
+		snapshot := stats.Snapshot()
+		assert.Equal(t, snapshot.Type, StatsSnapshotTypeServ)
+		assert.Equal(t, snapshot.Items["spin"].Times, int64(10*(i+1)))
+	}
+
+	snapshot, err := LoadStatsSnapshots(dir, "", StatsSnapshotTypeServ, true)
+	assert.NoError(t, err)
+	assert.Equal(t, snapshot.Type, StatsSnapshotTypeServ)
+	assert.Equal(t, snapshot.Sources, 2)
+	assert.Equal(t, snapshot.Items["spin"].Times, int64(30))
+	assert.Equal(t, snapshot.Items["spin"].Histogram.Count, int64(30))
+	assert.InDelta(t, snapshot.Items["spin"].Min, 0.010, 0.002)
+	assert.InDelta(t, snapshot.Items["spin"].Max, 0.020, 0.002)
+
+	report := snapshot.Report()
+	assert.Equal(t, len(report), 1)
+	assert.InDelta(t, report[0].P50, 0.020, 0.002)
+	assert.InDelta(t, report[0].P90, 0.020, 0.002)
+	assert.InDelta(t, report[0].P999, 0.020, 0.002)
+	assert.InDelta(t, report[0].Avg, (0.1+0.4)/30, 0.002)
+
+	buf := &bytes.Buffer{}
+	assert.NoError(t, snapshot.OutputReportCSV(buf))
+	assert.True(t, strings.HasPrefix(buf.String(), "name,times,total,avg,min,max,p50,p90,p99,p999,errTimes\nspin,30,"))
+
+	snapshot, err = LoadStatsSnapshots(dir, "game1", StatsSnapshotTypeServ, false)
+	assert.NoError(t, err)
+	assert.Equal(t, snapshot.Items["spin"].Times, int64(10))
+
+	sender := NewSenderStats(10, 0, time.Hour, dir, "sender")
+	sender.SetTimer(m)
+	sender.Push("msg", 100)
+	sender.Push("msg", 300)
+	sender.Output()
+
+	snapshot, err = LoadStatsSnapshots(dir, "sender", StatsSnapshotTypeSender, true)
+	assert.NoError(t, err)
+	assert.Equal(t, snapshot.Type, StatsSnapshotTypeSender)
+	assert.Equal(t, snapshot.Items["msg"].Total, 400.0)
+	assert.Equal(t, snapshot.Items["msg"].Min, 100.0)
+	assert.Equal(t, snapshot.Items["msg"].Max, 300.0)
+
+	merged, err := MergeStatsSnapshot(snapshot, sender.Snapshot())
+	assert.NoError(t, err)
+	assert.Equal(t, merged.Items["msg"].Times, int64(4))
+	assert.Equal(t, snapshot.Items["msg"].Times, int64(2))
+
+	// servstats + senderstats, filtered by type
+	snapshot, err = LoadStatsSnapshots(dir, "", StatsSnapshotTypeServ, true)
+	assert.NoError(t, err)
+	assert.Equal(t, snapshot.Sources, 2)
+	assert.Equal(t, snapshot.Items["spin"].Times, int64(30))
+
+	snapshot, err = LoadStatsSnapshots(dir, "", StatsSnapshotTypeSender, true)
+	assert.NoError(t, err)
+	assert.Equal(t, snapshot.Sources, 1)
+	assert.Equal(t, snapshot.Items["msg"].Total, 400.0)
+
+	// a SenderStats dump with min 0
+	sender = NewSenderStats(10, 0, time.Hour, dir, "sender0")
+	sender.SetTimer(m)
+	sender.Push("msg", 0)
+	sender.Output()
+
+	snapshot, err = LoadStatsSnapshots(dir, "sender0", StatsSnapshotTypeSender, true)
+	assert.NoError(t, err)
+	assert.Equal(t, snapshot.Type, StatsSnapshotTypeSender)
+	assert.Equal(t, snapshot.Items["msg"].Times, int64(1))
+	assert.Equal(t, snapshot.Items["msg"].Min, 0.0)
+
+	snapshot, err = LoadStatsSnapshots(dir, "sender0", StatsSnapshotTypeServ, true)
+	assert.NoError(t, err)
+	assert.Equal(t, snapshot.Sources, 0)
+	assert.Equal(t, len(snapshot.Items), 0)
+
+	t.Logf("Test_LoadStatsSnapshots OK")
+}
+
+func Test_LoadStatsSnapshotsFleet(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	dir := t.TempDir()
+
+	// 2 instances with the same prefix, 2 cumulative dumps of each instance
+	for i, instance := range []string{"host1", "host2"} {
+		m := NewMockITime(ctrl)
+		ts := int64(1597647600 + i)
+		m.EXPECT().Now().DoAndReturn(func() time.Time {
+			return time.Unix(ts, 0)
+		}).AnyTimes()
+
+		stats := NewServStats(10, 0, time.Hour, dir, 16, "game")
+		stats.SetTimer(m)
+		stats.SetInstance(instance)
+		stats.RegMsg("spin")
+
+		for j := 0; j < 2; j++ {
+			stats.EndMsg("spin", stats.StartMsg("spin"))
+			stats.Output()
+
+			ts += 10
+		}
+	}
+
+	lst, err := ListStatsFiles(dir, "game")
+	assert.NoError(t, err)
+	assert.Equal(t, len(lst), 4)
+
+	snapshot, err := LoadStatsSnapshots(dir, "game", StatsSnapshotTypeServ, true)
+	assert.NoError(t, err)
+	assert.Equal(t, snapshot.Sources, 2)
+	assert.Equal(t, snapshot.Items["spin"].Times, int64(4))
+
+	snapshot, err = LoadStatsSnapshots(dir, "game", StatsSnapshotTypeServ, false)
+	assert.NoError(t, err)
+	assert.Equal(t, snapshot.Sources, 4)
+	assert.Equal(t, snapshot.Items["spin"].Times, int64(6))
+
+	_, err = ParseStatsSnapshot([]byte(`{"type":"other","mapMsgs":{}}`))
+	assert.ErrorIs(t, err, ErrInvalidStatsSnapshotType)
+
+	t.Logf("Test_LoadStatsSnapshotsFleet OK")
+}
+
+func Test_LoadStatsSnapshotsRuntime(t *testing.T) {
+	dir := t.TempDir()
+
+	stats := NewServStats(10, 0, time.Hour, dir, 16, "servstats")
+	stats.RegMsg("spin")
+	stats.EndMsg("spin", stats.StartMsg("spin"))
+	stats.Output()
+
+	runtimeStats := NewRuntimeStats(time.Hour, time.Hour, dir, "runtime")
+	runtimeStats.Sample()
+	runtimeStats.Output()
+
+	lst, err := ListStatsFiles(dir, "runtime")
+	assert.NoError(t, err)
+	assert.Equal(t, len(lst), 1)
+
+	data, err := os.ReadFile(lst[0])
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"type":"runtimestats"`)
+
+	_, err = ParseStatsSnapshot(data)
+	assert.ErrorIs(t, err, ErrInvalidStatsSnapshotType)
+
+	// the RuntimeStats dumps are skipped
+	snapshot, err := LoadStatsSnapshots(dir, "", "", false)
+	assert.NoError(t, err)
+	assert.Equal(t, snapshot.Sources, 1)
+	assert.Equal(t, snapshot.Items["spin"].Times, int64(1))
+
+	_, err = LoadStatsSnapshots(dir, "", StatsSnapshotTypeRuntime, false)
+	assert.ErrorIs(t, err, ErrInvalidStatsSnapshotType)
+
+	t.Logf("Test_LoadStatsSnapshotsRuntime OK")
+}
@@ -0,0 +1,192 @@
+package goutils
+
+import (
+	"math"
+	"strings"
+	"time"
+)
+
+// DefaultStatsWindowSlot - default slot of RollingStats
+const DefaultStatsWindowSlot = 10 * time.Second
+
+// DefaultStatsWindows - last 1m, 5m, 15m
+var DefaultStatsWindows = []time.Duration{time.Minute, 5 * time.Minute, 15 * time.Minute}
+
+// WindowStats - values recorded in [Start, End)
+type WindowStats struct {
+	Window string  `json:"window"`
+	Start  int64   `json:"start"`
+	End    int64   `json:"end"`
+	Times  int64   `json:"times"`
+	Total  float64 `json:"total"`
+	Min    float64 `json:"min"`
+	Max    float64 `json:"max"`
+	Avg    float64 `json:"avg"`
+	Rate   float64 `json:"rate"`
+}
+
+type rollingSlot struct {
+	index int64
+	times int64
+	total float64
+	min   float64
+	max   float64
+}
+
+// RollingStats - ring-buffered slots, keeps the values of the last len(slots) * slot.
+// It is not thread-safe.
+type RollingStats struct {
+	slot  time.Duration
+	slots []rollingSlot
+}
+
+// NewRollingStats - new RollingStats, it can be queried for any window <= maxWindow
+func NewRollingStats(slot time.Duration, maxWindow time.Duration) *RollingStats {
+	if slot <= 0 {
+		slot = DefaultStatsWindowSlot
+	}
+
+	n := int((maxWindow + slot - 1) / slot)
+	if n < 1 {
+		n = 1
+	}
+
+	rs := &RollingStats{
+		slot:  slot,
+		slots: make([]rollingSlot, n),
+	}
+
+	for i := range rs.slots {
+		rs.slots[i].index = -1
+	}
+
+	return rs
+}
+
+func (rs *RollingStats) slotIndex(t time.Time) int64 {
+	return t.UnixNano() / int64(rs.slot)
+}
+
+// Record - record a value at t
+func (rs *RollingStats) Record(t time.Time, v float64) {
+	index := rs.slotIndex(t)
+	slot := &rs.slots[int(index%int64(len(rs.slots)))]
+
+	if slot.index != index {
+		slot.index = index
+		slot.times = 0
+		slot.total = 0
+		slot.min = math.MaxFloat64
+		slot.max = 0
+	}
+
+	slot.times++
+	slot.total += v
+
+	if v < slot.min {
+		slot.min = v
+	}
+
+	if v > slot.max {
+		slot.max = v
+	}
+}
+
+// Query - values of the last window before now, window is rounded up to slots
+func (rs *RollingStats) Query(now time.Time, window time.Duration) *WindowStats {
+	n := int64((window + rs.slot - 1) / rs.slot)
+	if n > int64(len(rs.slots)) {
+		n = int64(len(rs.slots))
+	}
+
+	if n < 1 {
+		n = 1
+	}
+
+	last := rs.slotIndex(now)
+	first := last - n + 1
+
+	ws := &WindowStats{
+		Window: FormatStatsWindow(window),
+		Start:  time.Unix(0, first*int64(rs.slot)).Unix(),
+		End:    now.Unix(),
+		Min:    math.MaxFloat64,
+	}
+
+	for _, slot := range rs.slots {
+		if slot.index < first || slot.index > last || slot.times == 0 {
+			continue
+		}
+
+		ws.Times += slot.times
+		ws.Total += slot.total
+
+		if slot.min < ws.Min {
+			ws.Min = slot.min
+		}
+
+		if slot.max > ws.Max {
+			ws.Max = slot.max
+		}
+	}
+
+	if ws.Times == 0 {
+		ws.Min = 0
+
+		return ws
+	}
+
+	ws.Avg = ws.Total / float64(ws.Times)
+
+	seconds := now.Sub(time.Unix(0, first*int64(rs.slot))).Seconds()
+	if seconds > 0 {
+		ws.Rate = float64(ws.Times) / seconds
+	}
+
+	return ws
+}
+
+// FormatStatsWindow - 1m0s -> 1m, 1h30m0s -> 1h30m
+func FormatStatsWindow(window time.Duration) string {
+	str := window.String()
+
+	if strings.HasSuffix(str, "m0s") {
+		str = str[:len(str)-2]
+	}
+
+	if strings.HasSuffix(str, "h0m") {
+		str = str[:len(str)-2]
+	}
+
+	return str
+}
+
+// newRollingStatsWithWindows - returns nil if windows is empty
+func newRollingStatsWithWindows(slot time.Duration, windows []time.Duration) *RollingStats {
+	if len(windows) == 0 {
+		return nil
+	}
+
+	maxWindow := windows[0]
+	for _, w := range windows {
+		if w > maxWindow {
+			maxWindow = w
+		}
+	}
+
+	return NewRollingStats(slot, maxWindow)
+}
+
+// queryWindows - returns nil if rs is nil
+func (rs *RollingStats) queryWindows(now time.Time, windows []time.Duration) []*WindowStats {
+	if rs == nil {
+		return nil
+	}
+
+	lst := make([]*WindowStats, 0, len(windows))
+	for _, w := range windows {
+		lst = append(lst, rs.Query(now, w))
+	}
+
+	return lst
+}
@@ -0,0 +1,150 @@
+package goutils
+
+import (
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	gomock "github.com/golang/mock/gomock"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_RollingStats(t *testing.T) {
+	rs := NewRollingStats(10*time.Second, 15*time.Minute)
+	assert.Equal(t, len(rs.slots), 90)
+
+	start := time.Unix(1597647600, 0)
+
+	// 1 value per second for 20 minutes, value = minute index
+	for i := 0; i < 20*60; i++ {
+		rs.Record(start.Add(time.Duration(i)*time.Second), float64(i/60))
+	}
+
+	now := start.Add(20*time.Minute - time.Second)
+
+	ws := rs.Query(now, time.Minute)
+	assert.Equal(t, ws.Window, "1m")
+	assert.Equal(t, ws.End, now.Unix())
+	assert.Equal(t, ws.Times, int64(60))
+	assert.Equal(t, ws.Min, 19.0)
+	assert.Equal(t, ws.Max, 19.0)
+
+	ws = rs.Query(now, 5*time.Minute)
+	assert.Equal(t, ws.Window, "5m")
+	assert.Equal(t, ws.Times, int64(300))
+	assert.Equal(t, ws.Min, 15.0)
+	assert.Equal(t, ws.Max, 19.0)
+	assert.InDelta(t, ws.Avg, 17, 1e-9)
+
+	// only 15 minutes are kept
+	ws = rs.Query(now, time.Hour)
+	assert.Equal(t, ws.Times, int64(900))
+	assert.Equal(t, ws.Min, 5.0)
+
+	// nothing in the last minute
+	ws = rs.Query(now.Add(2*time.Minute), time.Minute)
+	assert.Equal(t, ws.Times, int64(0))
+	assert.Equal(t, ws.Min, 0.0)
+
+	assert.Equal(t, FormatStatsWindow(90*time.Minute), "1h30m")
+	assert.Equal(t, FormatStatsWindow(time.Hour), "1h")
+	assert.Equal(t, FormatStatsWindow(30*time.Second), "30s")
+
+	t.Logf("Test_RollingStats OK")
+}
+
+func Test_ServStatsWindows(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := NewMockITime(ctrl)
+	m.EXPECT().Now().Return(time.Unix(1597647600, 0)).Times(1)
+	m.EXPECT().Now().Return(time.Unix(1597647630, 0)).Times(4)
+	m.EXPECT().Now().Return(time.Unix(1597647660, 0)).Times(1)
+
+	dir := t.TempDir()
+
+	stats := NewServStats(10, 0, time.Hour, dir, 16, "servstats")
+	stats.SetTimer(m)
+	stats.SetWindows(10*time.Second, time.Minute, 5*time.Minute)
+	stats.SetResetOnOutput(true)
+	stats.RegMsg("msg")
+
+	for i := 0; i < 3; i++ {
+		node := stats.StartMsg("msg")
+		stats.EndMsg("msg", node)
+	}
+
+	stats.Output()
+	stats.Output()
+
+	json := jsoniter.ConfigCompatibleWithStandardLibrary
+
+	data, err := os.ReadFile(path.Join(dir, "servstats.1597647630.json"))
+	assert.NoError(t, err)
+
+	output := &ServStats{}
+	assert.NoError(t, json.Unmarshal(data, output))
+	assert.Equal(t, output.StartTs, int64(1597647600))
+	assert.Equal(t, output.EndTs, int64(1597647630))
+	assert.Equal(t, output.MapMsgs["msg"].TotalTimes, 3)
+	assert.Equal(t, len(output.MapMsgs["msg"].Windows), 2)
+	assert.Equal(t, output.MapMsgs["msg"].Windows[0].Window, "1m")
+	assert.Equal(t, output.MapMsgs["msg"].Windows[0].Times, int64(3))
+
+	// the counters are reset, the windows are kept
+	data, err = os.ReadFile(path.Join(dir, "servstats.1597647660.json"))
+	assert.NoError(t, err)
+
+	output = &ServStats{}
+	assert.NoError(t, json.Unmarshal(data, output))
+	assert.Equal(t, output.StartTs, int64(1597647630))
+	assert.Equal(t, output.EndTs, int64(1597647660))
+	assert.Equal(t, output.MapMsgs["msg"].TotalTimes, 0)
+	assert.Equal(t, output.MapMsgs["msg"].Windows[0].Times, int64(3))
+
+	t.Logf("Test_ServStatsWindows OK")
+}
+
+func Test_SenderStatsWindows(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := NewMockITime(ctrl)
+	m.EXPECT().Now().Return(time.Unix(1597647600, 0)).Times(1)
+	m.EXPECT().Now().Return(time.Unix(1597647630, 0)).Times(3)
+	m.EXPECT().Now().Return(time.Unix(1597647660, 0)).Times(1)
+
+	dir := t.TempDir()
+
+	stats := NewSenderStats(10, 0, time.Hour, dir, "senderstats")
+	stats.SetTimer(m)
+	stats.SetWindows(10*time.Second, time.Minute)
+	stats.SetResetOnOutput(true)
+
+	stats.Push("msg", 100)
+	stats.Push("msg", 200)
+
+	stats.Output()
+
+	json := jsoniter.ConfigCompatibleWithStandardLibrary
+
+	data, err := os.ReadFile(path.Join(dir, "senderstats.1597647630.json"))
+	assert.NoError(t, err)
+
+	output := &SenderStats{}
+	assert.NoError(t, json.Unmarshal(data, output))
+	assert.Equal(t, output.StartTs, int64(1597647600))
+	assert.Equal(t, output.EndTs, int64(1597647630))
+	assert.Equal(t, output.MapNodes["msg"].TotalBytes, int64(300))
+	assert.Equal(t, output.MapNodes["msg"].Windows[0].Total, 300.0)
+
+	stats.Output()
+
+	assert.Equal(t, stats.StartTs, int64(1597647660))
+	assert.Equal(t, stats.MapNodes["msg"].TotalBytes, int64(0))
+
+	t.Logf("Test_SenderStatsWindows OK")
+}
@@ -0,0 +1,240 @@
+package goutils
+
+import (
+	"log/slog"
+	"strings"
+)
+
+// versionComparator - op is one of "=", "!=", ">", ">=", "<", "<="
+type versionComparator struct {
+	op   string
+	vobj *VersionObj
+}
+
+func (vc *versionComparator) check(vobj *VersionObj) bool {
+	ret := vobj.Compare(vc.vobj)
+
+	switch vc.op {
+	case "=":
+		return ret == 0
+	case "!=":
+		return ret != 0
+	case ">":
+		return ret > 0
+	case ">=":
+		return ret >= 0
+	case "<":
+		return ret < 0
+	case "<=":
+		return ret <= 0
+	}
+
+	return false
+}
+
+// VersionConstraint - the version ranges, like ">=1.2.0 <2.0.0 || ^3.1"
+//
+//	1.2.3, =1.2.3      exactly 1.2.3
+//	1.2, 1.2.x         >=1.2.0 <1.3.0
+//	*, x               any version
+//	!=1.2.3            not 1.2.3
+//	>1.2.3, >1.2       >1.2.3, >=1.3.0
+//	<=1.2.3, <=1.2     <=1.2.3, <1.3.0
+//	>=1.2, <1.2        >=1.2.0, <1.2.0
+//	^1.4, ^0.2.3       >=1.4.0 <2.0.0, >=0.2.3 <0.3.0
+//	~1.4.2, ~1         >=1.4.2 <1.5.0, >=1.0.0 <2.0.0
+//
+// The comparators separated by spaces or commas must all be satisfied, the groups separated by "||" are alternatives.
+// A pre-release version satisfies a group only if a comparator of the group has a pre-release with
+// the same major, minor and patch, so ">=1.2.0-rc.1" matches 1.2.0-rc.2 but not 1.3.0-rc.1.
+type VersionConstraint struct {
+	str    string
+	groups [][]*versionComparator
+}
+
+// ParseVersionConstraint - parse a constraint like ">=1.2.0 <2.0.0", "^1.4", "~1.4.2" or "1.x || >=2.1.0",
+// the versions are parsed like ParseVersionLenient
+func ParseVersionConstraint(str string) (*VersionConstraint, error) {
+	vc := &VersionConstraint{
+		str: strings.TrimSpace(str),
+	}
+
+	for _, strGroup := range strings.Split(str, "||") {
+		group, err := parseVersionConstraintGroup(strGroup)
+		if err != nil {
+			Warn("ParseVersionConstraint:parseVersionConstraintGroup",
+				slog.String("str", str),
+				slog.String("group", strGroup),
+				Err(err))
+
+			return nil, err
+		}
+
+		vc.groups = append(vc.groups, group)
+	}
+
+	return vc, nil
+}
+
+// String - the constraint string
+func (vc *VersionConstraint) String() string {
+	return vc.str
+}
+
+// Check - returns true if vobj satisfies the constraint
+func (vc *VersionConstraint) Check(vobj *VersionObj) bool {
+	for _, group := range vc.groups {
+		if checkVersionConstraintGroup(group, vobj) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CheckVersion - returns true if the version str satisfies the constraint
+func (vc *VersionConstraint) CheckVersion(str string) (bool, error) {
+	vobj, err := ParseVersionLenient(str)
+	if err != nil {
+		return false, err
+	}
+
+	return vc.Check(vobj), nil
+}
+
+func checkVersionConstraintGroup(group []*versionComparator, vobj *VersionObj) bool {
+	isPreReleaseAllowed := vobj.PreRelease == ""
+
+	for _, c := range group {
+		if !c.check(vobj) {
+			return false
+		}
+
+		if !isPreReleaseAllowed && c.vobj.PreRelease != "" && c.vobj.Major == vobj.Major &&
+			c.vobj.Minor == vobj.Minor && c.vobj.Patch == vobj.Patch {
+			isPreReleaseAllowed = true
+		}
+	}
+
+	return isPreReleaseAllowed
+}
+
+// parseVersionConstraintGroup - the comparators separated by spaces or commas, an operator can be followed by spaces
+func parseVersionConstraintGroup(str string) ([]*versionComparator, error) {
+	fields := strings.FieldsFunc(str, func(r rune) bool {
+		return r == ' ' || r == ',' || r == '\t' || r == '\n' || r == '\r'
+	})
+
+	group := []*versionComparator{}
+
+	for i := 0; i < len(fields); i++ {
+		item := fields[i]
+		if strings.TrimLeft(item, "=!<>^~") == "" && i+1 < len(fields) {
+			i++
+			item += fields[i]
+		}
+
+		lst, err := parseVersionComparator(item)
+		if err != nil {
+			return nil, err
+		}
+
+		group = append(group, lst...)
+	}
+
+	if len(group) == 0 {
+		return nil, ErrInvalidVersionConstraint
+	}
+
+	return group, nil
+}
+
+// parseVersionComparator - a comparator like "^1.4" is expanded to ">=1.4.0" and "<2.0.0"
+func parseVersionComparator(str string) ([]*versionComparator, error) {
+	op := ""
+	for _, cop := range []string{">=", "<=", "!=", "==", ">", "<", "=", "^", "~"} {
+		if strings.HasPrefix(str, cop) {
+			op = cop
+			str = str[len(cop):]
+
+			break
+		}
+	}
+
+	// the wildcard parts, like "1.x" or "1.2.*"
+	for _, w := range []string{".x", ".X", ".*"} {
+		for strings.HasSuffix(str, w) {
+			str = str[:len(str)-len(w)]
+		}
+	}
+
+	if str == "x" || str == "X" || str == "*" {
+		switch op {
+		case "", "=", "==", ">=", "<=", "^", "~":
+			return []*versionComparator{{op: ">=", vobj: &VersionObj{}}}, nil
+		}
+
+		return nil, ErrInvalidVersionConstraint
+	}
+
+	vobj, parts, err := parseVersion(str, versionModeLenient)
+	if err != nil {
+		return nil, ErrInvalidVersionConstraint
+	}
+
+	if parts < 3 && vobj.PreRelease != "" {
+		return nil, ErrInvalidVersionConstraint
+	}
+
+	switch op {
+	case "", "=", "==":
+		if parts == 3 {
+			return []*versionComparator{{op: "=", vobj: vobj}}, nil
+		}
+
+		return []*versionComparator{{op: ">=", vobj: vobj}, {op: "<", vobj: nextVersion(vobj, parts)}}, nil
+	case "!=":
+		if parts != 3 {
+			return nil, ErrInvalidVersionConstraint
+		}
+
+		return []*versionComparator{{op: "!=", vobj: vobj}}, nil
+	case ">":
+		if parts == 3 {
+			return []*versionComparator{{op: ">", vobj: vobj}}, nil
+		}
+
+		return []*versionComparator{{op: ">=", vobj: nextVersion(vobj, parts)}}, nil
+	case "<=":
+		if parts == 3 {
+			return []*versionComparator{{op: "<=", vobj: vobj}}, nil
+		}
+
+		return []*versionComparator{{op: "<", vobj: nextVersion(vobj, parts)}}, nil
+	case ">=", "<":
+		return []*versionComparator{{op: op, vobj: vobj}}, nil
+	case "~":
+		return []*versionComparator{{op: ">=", vobj: vobj}, {op: "<", vobj: nextVersion(vobj, min(parts, 2))}}, nil
+	}
+
+	// ^ - the left-most non-zero part can not be changed
+	if vobj.Major > 0 || parts == 1 {
+		parts = 1
+	} else if vobj.Minor > 0 || parts == 2 {
+		parts = 2
+	}
+
+	return []*versionComparator{{op: ">=", vobj: vobj}, {op: "<", vobj: nextVersion(vobj, parts)}}, nil
+}
+
+// nextVersion - the lowest version with the first parts higher than vobj, like 1.3.0 for 1.2.3 and 2
+func nextVersion(vobj *VersionObj, parts int) *VersionObj {
+	switch parts {
+	case 1:
+		return &VersionObj{Major: vobj.Major + 1}
+	case 2:
+		return &VersionObj{Major: vobj.Major, Minor: vobj.Minor + 1}
+	}
+
+	return &VersionObj{Major: vobj.Major, Minor: vobj.Minor, Patch: vobj.Patch + 1}
+}
@@ -0,0 +1,120 @@
+package goutils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_VersionCompare(t *testing.T) {
+	// the precedence example of SemVer 2.0
+	lst := []string{"v1.0.0-alpha", "v1.0.0-alpha.1", "v1.0.0-alpha.beta", "v1.0.0-beta", "v1.0.0-beta.2",
+		"v1.0.0-beta.11", "v1.0.0-rc.1", "v1.0.0", "v1.0.1", "v1.1.0", "v2.0.0"}
+
+	for i := 0; i < len(lst); i++ {
+		vi, err := ParseVersion(lst[i])
+		assert.NoError(t, err)
+
+		for j := 0; j < len(lst); j++ {
+			vj, err := ParseVersion(lst[j])
+			assert.NoError(t, err)
+
+			assert.Equal(t, vi.Compare(vj), compareInt(i, j), lst[i]+" "+lst[j])
+			assert.Equal(t, vi.Less(vj), i < j, lst[i]+" "+lst[j])
+			assert.Equal(t, vi.Equal(vj), i == j, lst[i]+" "+lst[j])
+		}
+	}
+
+	v0, err := ParseVersion("v1.2.3+build.1")
+	assert.NoError(t, err)
+
+	v1, err := ParseVersion("v1.2.3+build.2")
+	assert.NoError(t, err)
+
+	assert.True(t, v0.Equal(v1))
+
+	v0, err = ParseVersion("v1.2.3-rc.02")
+	assert.NoError(t, err)
+
+	v1, err = ParseVersion("v1.2.3-rc.10")
+	assert.NoError(t, err)
+
+	assert.True(t, v0.Less(v1))
+
+	t.Logf("Test_VersionCompare OK")
+}
+
+func Test_VersionConstraint(t *testing.T) {
+	type checkItem struct {
+		constraint string
+		version    string
+		isok       bool
+	}
+
+	lst := []checkItem{
+		{">=1.2.0 <2.0.0", "1.2.0", true},
+		{">=1.2.0 <2.0.0", "v1.9.9", true},
+		{">=1.2.0 <2.0.0", "2.0.0", false},
+		{">=1.2.0 <2.0.0", "1.1.9", false},
+		{">= 1.2.0, < 2.0.0", "1.5.0", true},
+		{"^1.4", "1.4.0", true},
+		{"^1.4", "1.99.1", true},
+		{"^1.4", "1.3.9", false},
+		{"^1.4", "2.0.0", false},
+		{"^0.2.3", "0.2.9", true},
+		{"^0.2.3", "0.3.0", false},
+		{"^0.0.3", "0.0.3", true},
+		{"^0.0.3", "0.0.4", false},
+		{"^0", "0.9.9", true},
+		{"~1.4.2", "1.4.9", true},
+		{"~1.4.2", "1.4.1", false},
+		{"~1.4.2", "1.5.0", false},
+		{"~1", "1.9.0", true},
+		{"~1", "2.0.0", false},
+		{"1.2", "1.2.7", true},
+		{"1.2.x", "1.3.0", false},
+		{"=1.2.3", "1.2.3+build.1", true},
+		{"1.2.3", "1.2.4", false},
+		{"!=1.2.3", "1.2.4", true},
+		{"!=1.2.3", "1.2.3", false},
+		{">1.2", "1.2.9", false},
+		{">1.2", "1.3.0", true},
+		{"<=1.2", "1.2.9", true},
+		{"<=1.2", "1.3.0", false},
+		{"*", "0.0.1", true},
+		{"^1.4 || ^2.1", "2.5.0", true},
+		{"^1.4 || ^2.1", "2.0.5", false},
+		{"<1.0.0 || >=3.0.0", "0.9.0", true},
+		{"<1.0.0 || >=3.0.0", "3.0.0", true},
+		{"<1.0.0 || >=3.0.0", "2.0.0", false},
+		// pre-releases
+		{"^1.4", "1.5.0-rc.1", false},
+		{">=1.2.0-rc.1", "1.2.0-rc.2", true},
+		{">=1.2.0-rc.1", "1.2.0", true},
+		{">=1.2.0-rc.1", "1.3.0-rc.1", false},
+		{">=1.2.0-rc.1 <2.0.0", "1.2.0-beta", false},
+	}
+
+	for _, item := range lst {
+		vc, err := ParseVersionConstraint(item.constraint)
+		assert.NoError(t, err, item.constraint)
+
+		isok, err := vc.CheckVersion(item.version)
+		assert.NoError(t, err)
+		assert.Equal(t, isok, item.isok, item.constraint+" "+item.version)
+	}
+
+	vc, err := ParseVersionConstraint(" ^1.4 || ~2.1.0 ")
+	assert.NoError(t, err)
+	assert.Equal(t, vc.String(), "^1.4 || ~2.1.0")
+
+	_, err = vc.CheckVersion("abc")
+	assert.ErrorIs(t, err, ErrInvalidVersion)
+
+	for _, str := range []string{"", "||", "^1.4 ||", ">=", "abc", ">=1.2.0 <x.y", "!=1.2", ">*", "^1.4-rc.1", "=>1.2.0"} {
+		_, err := ParseVersionConstraint(str)
+		assert.ErrorIs(t, err, ErrInvalidVersionConstraint, str)
+	}
+
+	t.Logf("Test_VersionConstraint OK")
+}
@@ -5,22 +5,108 @@ import (
 	"fmt"
 	"io/ioutil"
 	"log/slog"
+	"strconv"
 	"strings"
 	"text/template"
 )
 
+// VersionObj - a SemVer 2.0 version, like v1.2.3-rc.1+build.5
 type VersionObj struct {
 	Major int
 	Minor int
 	Patch int
+	// PreRelease - the dot-separated identifiers after '-', like "rc.1"
+	PreRelease string
+	// Build - the dot-separated build metadata after '+', like "build.5"
+	Build string
 }
 
+// String - like "v1.2.3-rc.1+build.5", it can be parsed by ParseVersion
+func (vobj *VersionObj) String() string {
+	str := fmt.Sprintf("v%v.%v.%v", vobj.Major, vobj.Minor, vobj.Patch)
+
+	if vobj.PreRelease != "" {
+		str += "-" + vobj.PreRelease
+	}
+
+	if vobj.Build != "" {
+		str += "+" + vobj.Build
+	}
+
+	return str
+}
+
+// ToString - same as String
 func (vobj *VersionObj) ToString() string {
-	return fmt.Sprintf("v%v.%v.%v", vobj.Major, vobj.Minor, vobj.Patch)
+	return vobj.String()
 }
 
+const (
+	// VersionPartMajor - the major part, see VersionObj.Bump
+	VersionPartMajor = "major"
+	// VersionPartMinor - the minor part, see VersionObj.Bump
+	VersionPartMinor = "minor"
+	// VersionPartPatch - the patch part, see VersionObj.Bump
+	VersionPartPatch = "patch"
+)
+
+// IncPatch - v1.2.3 to v1.2.4, the pre-release and build are removed
 func (vobj *VersionObj) IncPatch() {
 	vobj.Patch++
+	vobj.PreRelease = ""
+	vobj.Build = ""
+}
+
+// IncMinor - v1.2.3 to v1.3.0, the pre-release and build are removed
+func (vobj *VersionObj) IncMinor() {
+	vobj.Minor++
+	vobj.Patch = 0
+	vobj.PreRelease = ""
+	vobj.Build = ""
+}
+
+// IncMajor - v1.2.3 to v2.0.0, the pre-release and build are removed
+func (vobj *VersionObj) IncMajor() {
+	vobj.Major++
+	vobj.Minor = 0
+	vobj.Patch = 0
+	vobj.PreRelease = ""
+	vobj.Build = ""
+}
+
+// Bump - increase the part, it is VersionPartMajor, VersionPartMinor or VersionPartPatch
+func (vobj *VersionObj) Bump(part string) error {
+	switch part {
+	case VersionPartMajor:
+		vobj.IncMajor()
+	case VersionPartMinor:
+		vobj.IncMinor()
+	case VersionPartPatch:
+		vobj.IncPatch()
+	default:
+		Warn("VersionObj.Bump",
+			slog.String("part", part),
+			Err(ErrInvalidVersionPart))
+
+		return ErrInvalidVersionPart
+	}
+
+	return nil
+}
+
+// SetPreRelease - set the pre-release like "rc.1", an empty string removes it
+func (vobj *VersionObj) SetPreRelease(pre string) error {
+	if pre != "" && !isValidVersionIdentifiers(pre, true) {
+		Warn("VersionObj.SetPreRelease",
+			slog.String("pre", pre),
+			Err(ErrInvalidVersion))
+
+		return ErrInvalidVersion
+	}
+
+	vobj.PreRelease = pre
+
+	return nil
 }
 
 func LoadVersion(fn string) (string, error) {
@@ -32,51 +118,256 @@ func LoadVersion(fn string) (string, error) {
 	return string(data), nil
 }
 
+// SaveVersion - write vobj.String() to fn without a newline, it can be loaded by LoadVersion
+func SaveVersion(fn string, vobj *VersionObj) error {
+	err := ioutil.WriteFile(fn, []byte(vobj.String()), 0644)
+	if err != nil {
+		Warn("SaveVersion:WriteFile",
+			slog.String("fn", fn),
+			Err(err))
+
+		return err
+	}
+
+	return nil
+}
+
+const (
+	// versionModeDefault - 'v' is required, leading zeros are allowed, whitespaces are trimmed
+	versionModeDefault = 0
+	// versionModeStrict - SemVer 2.0 with an optional 'v', no leading zeros, no whitespaces
+	versionModeStrict = 1
+	// versionModeLenient - 'v' is optional, leading zeros are allowed, whitespaces are trimmed,
+	// the minor and patch can be omitted
+	versionModeLenient = 2
+)
+
+// ParseVersion - parse a version like "v1.2.3", "v1.2.3-rc.1+build.5" or "v1.02.3\n",
+// the 'v' is required, the surrounding whitespaces are trimmed
 func ParseVersion(str string) (*VersionObj, error) {
-	if str[0:1] != "v" && str[0:1] != "V" {
-		Warn("ParseVersion",
-			slog.String("str", str))
+	return parseVersionWithMode("ParseVersion", str, versionModeDefault)
+}
+
+// ParseVersionStrict - parse a SemVer 2.0 version like "1.2.3-rc.1+build.5" or "v1.2.3",
+// the numbers must not have leading zeros and whitespaces are not allowed
+func ParseVersionStrict(str string) (*VersionObj, error) {
+	return parseVersionWithMode("ParseVersionStrict", str, versionModeStrict)
+}
+
+// ParseVersionLenient - parse a version like "1.2.3", "v1.02", "V1" or " 1.2.3-rc.1\n",
+// the 'v' is optional, the omitted minor and patch are 0
+func ParseVersionLenient(str string) (*VersionObj, error) {
+	return parseVersionWithMode("ParseVersionLenient", str, versionModeLenient)
+}
+
+func parseVersionWithMode(funcName string, str string, mode int) (*VersionObj, error) {
+	vobj, _, err := parseVersion(str, mode)
+	if err != nil {
+		Warn(funcName,
+			slog.String("str", str),
+			Err(err))
 
-		return nil, ErrInvalidVersion
+		return nil, err
 	}
 
-	arr := strings.Split(str[1:], ".")
-	if len(arr) != 3 {
-		Warn("ParseVersion:Split",
-			slog.String("str", str))
+	return vobj, nil
+}
 
-		return nil, ErrInvalidVersion
+// parseVersion - returns the version and the number of its major, minor and patch parts
+func parseVersion(str string, mode int) (*VersionObj, int, error) {
+	if mode != versionModeStrict {
+		str = strings.TrimSpace(str)
 	}
 
-	v0, err := String2Int64(arr[0])
-	if err != nil {
-		Warn("ParseVersion:String2Int64:arr0",
-			slog.String("str", str))
+	if str == "" {
+		return nil, 0, ErrInvalidVersion
+	}
 
-		return nil, ErrInvalidVersion
+	if str[0] == 'v' || str[0] == 'V' {
+		str = str[1:]
+	} else if mode == versionModeDefault {
+		return nil, 0, ErrInvalidVersion
 	}
 
-	v1, err := String2Int64(arr[1])
-	if err != nil {
-		Warn("ParseVersion:String2Int64:arr1",
-			slog.String("str", str))
+	vobj := &VersionObj{}
+
+	str, build, isBuild := strings.Cut(str, "+")
+	if isBuild {
+		if !isValidVersionIdentifiers(build, false) {
+			return nil, 0, ErrInvalidVersion
+		}
 
-		return nil, ErrInvalidVersion
+		vobj.Build = build
 	}
 
-	v2, err := String2Int64(arr[2])
-	if err != nil {
-		Warn("ParseVersion:String2Int64:arr2",
-			slog.String("str", str))
+	str, pre, isPre := strings.Cut(str, "-")
+	if isPre {
+		if !isValidVersionIdentifiers(pre, mode == versionModeStrict) {
+			return nil, 0, ErrInvalidVersion
+		}
+
+		vobj.PreRelease = pre
+	}
+
+	arr := strings.Split(str, ".")
+	if len(arr) > 3 || (len(arr) != 3 && mode != versionModeLenient) {
+		return nil, 0, ErrInvalidVersion
+	}
+
+	nums := []*int{&vobj.Major, &vobj.Minor, &vobj.Patch}
+	for i, cs := range arr {
+		if !isVersionNumber(cs, mode == versionModeStrict) {
+			return nil, 0, ErrInvalidVersion
+		}
+
+		n, err := strconv.Atoi(cs)
+		if err != nil {
+			return nil, 0, ErrInvalidVersion
+		}
+
+		*nums[i] = n
+	}
+
+	return vobj, len(arr), nil
+}
+
+// isVersionNumber - only digits, leading zeros are not allowed if isStrict
+func isVersionNumber(str string, isStrict bool) bool {
+	if str == "" {
+		return false
+	}
+
+	for _, c := range str {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+
+	return !isStrict || str == "0" || str[0] != '0'
+}
+
+// isValidVersionIdentifiers - non-empty dot-separated identifiers of [0-9A-Za-z-],
+// the numeric identifiers must not have leading zeros if isStrict
+func isValidVersionIdentifiers(str string, isStrict bool) bool {
+	for _, id := range strings.Split(str, ".") {
+		if id == "" {
+			return false
+		}
+
+		isNumber := true
+		for _, c := range id {
+			if c >= '0' && c <= '9' {
+				continue
+			}
+
+			isNumber = false
+
+			if !(c >= 'a' && c <= 'z') && !(c >= 'A' && c <= 'Z') && c != '-' {
+				return false
+			}
+		}
+
+		if isNumber && isStrict && id != "0" && id[0] == '0' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Compare - returns -1, 0 or 1 if vobj is lower than, equal to or higher than other with the SemVer 2.0 precedence,
+// the Build is ignored
+func (vobj *VersionObj) Compare(other *VersionObj) int {
+	if vobj.Major != other.Major {
+		return compareInt(vobj.Major, other.Major)
+	}
+
+	if vobj.Minor != other.Minor {
+		return compareInt(vobj.Minor, other.Minor)
+	}
+
+	if vobj.Patch != other.Patch {
+		return compareInt(vobj.Patch, other.Patch)
+	}
+
+	return comparePreRelease(vobj.PreRelease, other.PreRelease)
+}
+
+// Less - vobj < other
+func (vobj *VersionObj) Less(other *VersionObj) bool {
+	return vobj.Compare(other) < 0
+}
+
+// Equal - vobj and other have the same precedence, the Build is ignored
+func (vobj *VersionObj) Equal(other *VersionObj) bool {
+	return vobj.Compare(other) == 0
+}
+
+func compareInt(a, b int) int {
+	if a < b {
+		return -1
+	}
+
+	if a > b {
+		return 1
+	}
+
+	return 0
+}
+
+// comparePreRelease - a version without pre-release is higher,
+// the numeric identifiers are compared numerically and are lower than the alphanumeric ones,
+// a larger set of identifiers is higher if all the preceding ones are equal
+func comparePreRelease(a, b string) int {
+	if a == b {
+		return 0
+	}
+
+	if a == "" {
+		return 1
+	}
+
+	if b == "" {
+		return -1
+	}
+
+	arrA := strings.Split(a, ".")
+	arrB := strings.Split(b, ".")
+
+	for i := 0; i < len(arrA) && i < len(arrB); i++ {
+		ret := comparePreReleaseIdentifier(arrA[i], arrB[i])
+		if ret != 0 {
+			return ret
+		}
+	}
+
+	return compareInt(len(arrA), len(arrB))
+}
+
+func comparePreReleaseIdentifier(a, b string) int {
+	isNumA := isVersionNumber(a, false)
+	isNumB := isVersionNumber(b, false)
+
+	if isNumA && isNumB {
+		a = strings.TrimLeft(a, "0")
+		b = strings.TrimLeft(b, "0")
+
+		if len(a) != len(b) {
+			return compareInt(len(a), len(b))
+		}
+
+		return strings.Compare(a, b)
+	}
+
+	if isNumA {
+		return -1
+	}
 
-		return nil, ErrInvalidVersion
+	if isNumB {
+		return 1
 	}
 
-	return &VersionObj{
-		Major: int(v0),
-		Minor: int(v1),
-		Patch: int(v2),
-	}, nil
+	return strings.Compare(a, b)
 }
 
 func BuildVersionFile(fn string, tmpfn string, vobj *VersionObj) error {
@@ -37,6 +37,122 @@ func Test_ParseVersion(t *testing.T) {
 	t.Logf("Test_LoadVersion OK")
 }
 
+func Test_ParseVersionSemVer(t *testing.T) {
+	_, err := ParseVersion("")
+	assert.ErrorIs(t, err, ErrInvalidVersion)
+
+	_, err = ParseVersion("v")
+	assert.ErrorIs(t, err, ErrInvalidVersion)
+
+	vobj, err := ParseVersion(" v0.2.2\n")
+	assert.NoError(t, err)
+	assert.Equal(t, vobj, &VersionObj{Major: 0, Minor: 2, Patch: 2})
+
+	vobj, err = ParseVersion("v1.2.3-rc.1+build.5")
+	assert.NoError(t, err)
+	assert.Equal(t, vobj, &VersionObj{Major: 1, Minor: 2, Patch: 3, PreRelease: "rc.1", Build: "build.5"})
+	assert.Equal(t, vobj.String(), "v1.2.3-rc.1+build.5")
+
+	vobj, err = ParseVersion("V1.0.0-alpha-1.x+20240101")
+	assert.NoError(t, err)
+	assert.Equal(t, vobj.PreRelease, "alpha-1.x")
+	assert.Equal(t, vobj.Build, "20240101")
+
+	for _, str := range []string{"v1.2.3-", "v1.2.3+", "v1.2.3-rc..1", "v1.2.3-rc_1", "v1.2.3.4", "v1.-2.3", "v1.2.+3"} {
+		_, err = ParseVersion(str)
+		assert.ErrorIs(t, err, ErrInvalidVersion, str)
+	}
+
+	// strict
+	vobj, err = ParseVersionStrict("1.2.3-rc.1+build.05")
+	assert.NoError(t, err)
+	assert.Equal(t, vobj.String(), "v1.2.3-rc.1+build.05")
+
+	vobj, err = ParseVersionStrict("v0.0.0")
+	assert.NoError(t, err)
+	assert.Equal(t, vobj.String(), "v0.0.0")
+
+	for _, str := range []string{"1.02.3", "1.2.3-rc.01", " 1.2.3", "1.2.3\n", "1.2", ""} {
+		_, err = ParseVersionStrict(str)
+		assert.ErrorIs(t, err, ErrInvalidVersion, str)
+	}
+
+	// lenient
+	vobj, err = ParseVersionLenient(" 1.02-beta\n")
+	assert.NoError(t, err)
+	assert.Equal(t, vobj, &VersionObj{Major: 1, Minor: 2, PreRelease: "beta"})
+
+	vobj, err = ParseVersionLenient("V3")
+	assert.NoError(t, err)
+	assert.Equal(t, vobj.String(), "v3.0.0")
+
+	for _, str := range []string{"", "v", "1..2", "x.y.z", "1.2.3.4"} {
+		_, err = ParseVersionLenient(str)
+		assert.ErrorIs(t, err, ErrInvalidVersion, str)
+	}
+
+	// round-trip
+	for _, str := range []string{"v0.2.2", "v1.0.0-0.3.7", "v1.0.0-x.7.z.92", "v1.0.0+21AF26D3-117B344092BD", "v10.20.30-rc.1+meta"} {
+		vobj, err = ParseVersion(str)
+		assert.NoError(t, err)
+		assert.Equal(t, vobj.String(), str)
+	}
+
+	str, err := LoadVersion("./VERSION")
+	assert.NoError(t, err)
+
+	vobj, err = ParseVersion(str)
+	assert.NoError(t, err)
+	assert.Equal(t, vobj.String(), Version)
+
+	t.Logf("Test_ParseVersionSemVer OK")
+}
+
+func Test_BumpVersion(t *testing.T) {
+	vobj, err := ParseVersion("v1.2.3-rc.1+build.5")
+	assert.NoError(t, err)
+
+	vobj.IncPatch()
+	assert.Equal(t, vobj.String(), "v1.2.4")
+
+	vobj.IncMinor()
+	assert.Equal(t, vobj.String(), "v1.3.0")
+
+	assert.NoError(t, vobj.SetPreRelease("beta.2"))
+	assert.Equal(t, vobj.String(), "v1.3.0-beta.2")
+
+	vobj.IncMajor()
+	assert.Equal(t, vobj.String(), "v2.0.0")
+
+	assert.NoError(t, vobj.Bump(VersionPartMinor))
+	assert.Equal(t, vobj.String(), "v2.1.0")
+
+	assert.NoError(t, vobj.Bump(VersionPartPatch))
+	assert.Equal(t, vobj.String(), "v2.1.1")
+
+	assert.NoError(t, vobj.Bump(VersionPartMajor))
+	assert.Equal(t, vobj.String(), "v3.0.0")
+
+	assert.ErrorIs(t, vobj.Bump("build"), ErrInvalidVersionPart)
+	assert.ErrorIs(t, vobj.SetPreRelease("rc.01"), ErrInvalidVersion)
+	assert.ErrorIs(t, vobj.SetPreRelease("rc..1"), ErrInvalidVersion)
+	assert.Equal(t, vobj.String(), "v3.0.0")
+
+	assert.NoError(t, vobj.SetPreRelease("rc.1"))
+	assert.NoError(t, vobj.SetPreRelease(""))
+	assert.Equal(t, vobj.String(), "v3.0.0")
+
+	fn := t.TempDir() + "/VERSION"
+
+	assert.NoError(t, SaveVersion(fn, vobj))
+
+	str, err := LoadVersion(fn)
+	assert.NoError(t, err)
+	assert.Equal(t, str, "v3.0.0")
+
+	t.Logf("Test_BumpVersion OK")
+}
+
 func Test_BuildVersionFile(t *testing.T) {
 	vobj0, err0 := ParseVersion("v1.20.03")
 	assert.NoError(t, err0)